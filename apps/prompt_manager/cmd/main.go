@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 
 	"github.com/gorilla/mux"
+	"github.com/claude-code-template/prompt-manager/database/migrations"
 	"github.com/claude-code-template/prompt-manager/internal/api"
+	"github.com/claude-code-template/prompt-manager/internal/api/handlers"
 	"github.com/claude-code-template/prompt-manager/internal/database"
+	"github.com/claude-code-template/prompt-manager/internal/events"
 )
 
 const (
@@ -23,43 +28,189 @@ func main() {
 
 	// Initialize database
 	config := database.DefaultConfig()
+	// EMBEDDED_MIGRATIONS=1 reads the schema baked into the binary at build
+	// time instead of config.MigrationsDir off disk — useful for a
+	// deployment that doesn't ship the migrations directory alongside the
+	// binary. Dev mode leaves this unset so edits under database/migrations
+	// are picked up without a rebuild.
+	if os.Getenv("EMBEDDED_MIGRATIONS") != "" {
+		config.MigrationsFS = migrations.FS
+	}
+	// AUTO_MIGRATE defaults to true so this template keeps working out of
+	// the box; set it to "false" for a deployment that wants to refuse to
+	// start on a stale schema rather than silently migrating it.
+	config.AutoMigrate = os.Getenv("AUTO_MIGRATE") != "false"
+
 	db, err := database.New(config)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer db.Close()
 
-	// Run migrations
-	if err := db.RunMigrations(config.MigrationsDir); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	// CheckCompatibility refuses to start if the database is ahead of this
+	// binary's database.SchemaVersion (an older binary connecting to a
+	// database a newer one already migrated), and otherwise applies any
+	// pending migrations itself since AUTO_MIGRATE defaults to true above.
+	if err := db.CheckCompatibility(); err != nil {
+		log.Fatalf("Database schema is incompatible with this binary: %v", err)
 	}
 
+	// eventBus is shared between the API server and the prompt hook handler
+	// so hook-submitted messages and browser-driven mutations all land on
+	// the same SSE streams.
+	eventBus := events.NewBus()
+
 	// Initialize API server
-	server := api.NewServer(db)
+	server := api.NewServer(db, api.WithEventBus(eventBus))
+
+	// Issue the shared hook token the Claude Code hook scripts authenticate
+	// with, persisting it alongside the database so it survives restarts.
+	hookToken, err := handlers.LoadOrCreateHookToken(filepath.Join(filepath.Dir(config.DatabasePath), "hook.token"))
+	if err != nil {
+		log.Fatalf("Failed to load hook token: %v", err)
+	}
+
+	// HMAC secret for hook clients that sign requests (X-Signature/
+	// X-Timestamp) instead of presenting the shared hook token.
+	hmacSecret, err := handlers.LoadOrCreateHookToken(filepath.Join(filepath.Dir(config.DatabasePath), "hmac.secret"))
+	if err != nil {
+		log.Fatalf("Failed to load HMAC secret: %v", err)
+	}
+
+	// Auxiliary event sinks (a webhook + an NDJSON file tail) are opt-in via
+	// environment variables so a deployment that sets neither behaves
+	// exactly as before; the event bus above is always wired in as the
+	// default sink so the SSE streams keep working either way.
+	promptOpts := []handlers.PromptHandlerOption{handlers.WithPromptEventBus(eventBus)}
+	if webhookURL := os.Getenv("EVENT_WEBHOOK_URL"); webhookURL != "" {
+		queueDir := filepath.Join(filepath.Dir(config.DatabasePath), "webhook-queue")
+		webhookSink := handlers.NewWebhookSink(webhookURL, queueDir)
+		promptOpts = append(promptOpts, handlers.WithPromptEventSink(webhookSink))
+		// Retries whatever a past outage (or a restart mid-outage) left
+		// queued on disk; without this, FlushQueue is never called and
+		// queued deliveries sit there forever.
+		go webhookSink.StartFlushLoop(context.Background(), handlers.DefaultWebhookFlushInterval)
+	}
+	if eventLogDir := os.Getenv("EVENT_LOG_DIR"); eventLogDir != "" {
+		promptOpts = append(promptOpts, handlers.WithPromptEventSink(handlers.NewFileSink(eventLogDir, "events")))
+	}
+
+	// SessionEventSink is a separate opt-in from the prompt/response sinks
+	// above: it fires on SessionStart/SessionEnd instead of every message, so
+	// a dashboard watching session-level activity doesn't have to filter a
+	// prompt feed for lifecycle events.
+	var sessionOpts []handlers.SessionHandlerOption
+	if sessionWebhookURL := os.Getenv("SESSION_WEBHOOK_URL"); sessionWebhookURL != "" {
+		sessionOpts = append(sessionOpts, handlers.WithSessionEventSink(handlers.NewWebhookSessionSink(sessionWebhookURL)))
+	}
+	sessionHandler := handlers.NewSessionHandler(db, sessionOpts...)
+	promptHandler := handlers.NewPromptHandler(db, promptOpts...)
+	responseHandler := handlers.NewResponseHandler(db)
+	batchHandler := handlers.NewBatchHandler(promptHandler, responseHandler)
+
+	adminHandler := handlers.NewAdminHandler(db)
+
+	// Token for the /admin maintenance endpoints (backup, vacuum, integrity
+	// check, checkpoint), separate from the hook token since these are
+	// operator actions rather than something Claude Code's hook scripts
+	// ever call.
+	adminToken, err := handlers.LoadOrCreateHookToken(filepath.Join(filepath.Dir(config.DatabasePath), "admin.token"))
+	if err != nil {
+		log.Fatalf("Failed to load admin token: %v", err)
+	}
+
+	// JWT secret for the /api/v2 mount point; same persist-alongside-the-DB
+	// approach as the hook token above.
+	jwtSecretHex, err := handlers.LoadOrCreateHookToken(filepath.Join(filepath.Dir(config.DatabasePath), "jwt.secret"))
+	if err != nil {
+		log.Fatalf("Failed to load JWT secret: %v", err)
+	}
+	jwtSecret := []byte(jwtSecretHex)
 
 	// Setup routes
 	router := mux.NewRouter()
-	
+	router.Use(handlers.NewLoggingMiddleware(handlers.StdLogger{}))
+
 	// Health check endpoint
 	router.HandleFunc("/health", server.HealthHandler).Methods("GET")
-	
-	// API routes
-	api := router.PathPrefix("/api/v1").Subrouter()
-	
+
+	// Hook endpoints: authenticated with the shared bearer token issued above
+	messages := router.PathPrefix("/messages").Subrouter()
+	messages.Use(handlers.NewAuthMiddleware(handlers.AuthOptions{HookToken: hookToken, HMACSecret: hmacSecret}))
+	// A hook client that retries after a timeout can resend the same
+	// Idempotency-Key; replaying the stored response keeps that retry from
+	// creating a second message.
+	messages.Use(handlers.NewIdempotencyMiddleware(db))
+	messages.Handle("/session", handlers.Invoke(sessionHandler.HandleSessionEvent)).Methods("POST")
+	messages.HandleFunc("/batch", batchHandler.HandleBatchSubmit).Methods("POST")
+	messages.Handle("/prompt", handlers.InvokeWithStatus(promptHandler.HandlePromptSubmit, http.StatusCreated)).Methods("POST")
+
+	// Admin endpoints: operator-only maintenance operations, authenticated
+	// with their own bearer token rather than the hook token or XSRF cookie
+	// a browser session would present.
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(handlers.NewAuthMiddleware(handlers.AuthOptions{HookToken: adminToken}))
+	admin.Handle("/backup", handlers.Invoke(adminHandler.HandleBackup)).Methods("POST")
+	admin.Handle("/vacuum", handlers.Invoke(adminHandler.HandleVacuum)).Methods("POST")
+	admin.Handle("/integrity-check", handlers.Invoke(adminHandler.HandleIntegrityCheck)).Methods("POST")
+	admin.Handle("/checkpoint", handlers.Invoke(adminHandler.HandleCheckpoint)).Methods("POST")
+	admin.Handle("/stats", handlers.Invoke(adminHandler.HandleStats)).Methods("GET")
+
+	// API routes: browser-driven, so mutating requests require a matching
+	// double-submit XSRF cookie/header pair instead of the hook token. The
+	// composable stack below (gzip, CORS, per-IP rate limiting) applies to
+	// every /api/v1 route alongside that existing auth.
+	rateLimiter := api.NewRateLimiter(10, 20)
+
+	v1 := api.NewRouter(router, "/api/v1")
+	v1.Use(handlers.NewAuthMiddleware(handlers.AuthOptions{RequireXSRF: true}))
+	v1.Use(api.GzipMiddleware)
+	v1.Use(api.CORSMiddleware(nil))
+	v1.Use(rateLimiter.Middleware)
+
 	// Conversation endpoints
-	api.HandleFunc("/conversations", server.ListConversationsHandler).Methods("GET")
-	api.HandleFunc("/conversations", server.CreateConversationHandler).Methods("POST")
-	api.HandleFunc("/conversations/{id}", server.GetConversationHandler).Methods("GET")
-	api.HandleFunc("/conversations/{id}", server.UpdateConversationHandler).Methods("PUT")
-	api.HandleFunc("/conversations/{id}", server.DeleteConversationHandler).Methods("DELETE")
-	
+	v1.HandleFunc("GET", "/conversations", server.ListConversationsHandler)
+	v1.HandleFunc("POST", "/conversations", server.CreateConversationHandler)
+	v1.Handle("GET", "/conversations/{id}", server.GetConversationHandler)
+	v1.HandleFunc("GET", "/conversations/{id}/messages", server.GetConversationMessagesHandler)
+	v1.HandleFunc("PUT", "/conversations/{id}", server.UpdateConversationHandler)
+	v1.HandleFunc("DELETE", "/conversations/{id}", server.DeleteConversationHandler)
+
+	// Search endpoint
+	v1.HandleFunc("GET", "/search", server.SearchHandler)
+
+	// Server-Sent Events streams for live updates
+	v1.HandleFunc("GET", "/stream", server.StreamHandler)
+	v1.HandleFunc("GET", "/events/stream", server.EventsStreamHandler)
+	v1.HandleFunc("GET", "/conversations/{id}/stream", server.ConversationStreamHandler)
+
 	// Rating endpoints
-	api.HandleFunc("/conversations/{id}/ratings", server.CreateConversationRatingHandler).Methods("POST")
-	api.HandleFunc("/conversations/{id}/ratings", server.GetConversationRatingsHandler).Methods("GET")
-	api.HandleFunc("/ratings/{id}", server.UpdateRatingHandler).Methods("PUT")
-	api.HandleFunc("/ratings/{id}", server.DeleteRatingHandler).Methods("DELETE")
-	api.HandleFunc("/ratings/stats", server.GetRatingStatsHandler).Methods("GET")
-	
+	v1.HandleFunc("POST", "/conversations/{id}/ratings", server.CreateConversationRatingHandler)
+	v1.HandleFunc("GET", "/conversations/{id}/ratings", server.GetConversationRatingsHandler)
+	v1.HandleFunc("PUT", "/ratings/{id}", server.UpdateRatingHandler)
+	v1.HandleFunc("DELETE", "/ratings/{id}", server.DeleteRatingHandler)
+	v1.HandleFunc("GET", "/ratings/stats", server.GetRatingStatsHandler)
+	v1.Handle("POST", "/messages/{id}/ratings", server.CreateMessageRatingHandler)
+	v1.Handle("GET", "/analytics/ratings", server.GetRatingAnalyticsHandler)
+
+	// Tagging
+	v1.Handle("POST", "/conversations/{id}/tags", server.AddConversationTagHandler)
+	v1.Handle("DELETE", "/conversations/{id}/tags/{tag}", server.RemoveConversationTagHandler)
+	v1.Handle("GET", "/tags", server.ListTagsHandler)
+
+	// Bulk export/import
+	v1.Handle("GET", "/export", server.ExportConversationsHandler)
+	v1.Handle("POST", "/import", server.ImportConversationsHandler)
+
+	// /api/v2 has no routes yet; it exists so future breaking changes have
+	// somewhere to land without disturbing v1 clients. It gets the same
+	// stack plus JWT auth on mutating routes in place of the v1 XSRF check.
+	v2 := api.NewRouter(router, "/api/v2")
+	v2.Use(api.JWTMiddleware(jwtSecret))
+	v2.Use(api.GzipMiddleware)
+	v2.Use(api.CORSMiddleware(nil))
+	v2.Use(rateLimiter.Middleware)
+
 	fmt.Printf("Starting Prompt Manager server on port %s\n", port)
 	fmt.Printf("Database: %s\n", config.DatabasePath)
 	log.Fatal(http.ListenAndServe(":"+port, router))
@@ -0,0 +1,52 @@
+// Package driver abstracts the handful of SQL dialect differences the
+// prompt-manager repository methods depend on, so a query can be written
+// once with `?` placeholders and rebound to whichever backend is configured
+// instead of hand-maintaining a dialect-specific copy of every query.
+package driver
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Driver abstracts placeholder syntax, foreign key enforcement, and upsert
+// syntax across the database backends prompt-manager supports. Dialect
+// features beyond that (SQLite's FTS5 full-text search, GROUP_CONCAT) are
+// still written directly against the one backend that supports them and
+// are out of scope here.
+type Driver interface {
+	// Name identifies the driver, e.g. "sqlite" or "postgres", also used to
+	// select a dialect-suffixed migration file (001_initial.postgres.up.sql)
+	// over the plain shared one.
+	Name() string
+
+	// DriverName is the database/sql driver name to pass to sql.Open.
+	DriverName() string
+
+	// Rebind rewrites a query written with `?` placeholders into this
+	// driver's native placeholder syntax.
+	Rebind(query string) string
+
+	// EnableForeignKeys runs whatever this driver needs to turn on foreign
+	// key enforcement on conn, or does nothing if the backend always
+	// enforces them.
+	EnableForeignKeys(conn *sql.DB) error
+
+	// Upsert returns an "ON CONFLICT(conflictColumn) DO ..." clause: DO
+	// NOTHING when setColumns is empty, otherwise DO UPDATE SET each column
+	// to its excluded value.
+	Upsert(conflictColumn string, setColumns []string) string
+}
+
+// For returns the Driver named name. An empty name defaults to "sqlite",
+// matching prompt-manager's original SQLite-only behavior.
+func For(name string) (Driver, error) {
+	switch name {
+	case "", "sqlite":
+		return SQLite{}, nil
+	case "postgres":
+		return Postgres{}, nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", name)
+	}
+}
@@ -0,0 +1,50 @@
+package driver
+
+import "testing"
+
+func TestPostgres_Rebind_NumbersPlaceholdersInOrder(t *testing.T) {
+	got := Postgres{}.Rebind("SELECT * FROM t WHERE a = ? AND b = ?")
+	want := "SELECT * FROM t WHERE a = $1 AND b = $2"
+	if got != want {
+		t.Errorf("Rebind() = %q, want %q", got, want)
+	}
+}
+
+func TestSQLite_Rebind_PassesPlaceholdersThrough(t *testing.T) {
+	query := "SELECT * FROM t WHERE a = ? AND b = ?"
+	if got := (SQLite{}).Rebind(query); got != query {
+		t.Errorf("Rebind() = %q, want unchanged %q", got, query)
+	}
+}
+
+func TestFor_UnknownDriverErrors(t *testing.T) {
+	if _, err := For("mysql"); err == nil {
+		t.Fatal("Expected For to error for an unrecognized driver name")
+	}
+}
+
+func TestFor_EmptyNameDefaultsToSQLite(t *testing.T) {
+	d, err := For("")
+	if err != nil {
+		t.Fatalf("Failed to select default driver: %v", err)
+	}
+	if d.Name() != "sqlite" {
+		t.Errorf("Expected empty driver name to default to sqlite, got %q", d.Name())
+	}
+}
+
+func TestUpsertClause_NoSetColumnsDoesNothing(t *testing.T) {
+	got := upsertClause("session_id", nil)
+	want := "ON CONFLICT(session_id) DO NOTHING"
+	if got != want {
+		t.Errorf("Upsert() = %q, want %q", got, want)
+	}
+}
+
+func TestUpsertClause_WithSetColumnsUpdatesFromExcluded(t *testing.T) {
+	got := upsertClause("session_id", []string{"title", "updated_at"})
+	want := "ON CONFLICT(session_id) DO UPDATE SET title = excluded.title, updated_at = excluded.updated_at"
+	if got != want {
+		t.Errorf("Upsert() = %q, want %q", got, want)
+	}
+}
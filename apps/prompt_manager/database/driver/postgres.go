@@ -0,0 +1,44 @@
+package driver
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+// Postgres lets prompt-manager run against an existing Postgres instance
+// instead of forking the schema and queries for it. Postgres always
+// enforces foreign keys, so EnableForeignKeys is a no-op.
+type Postgres struct{}
+
+func (Postgres) Name() string       { return "postgres" }
+func (Postgres) DriverName() string { return "postgres" }
+
+// Rebind rewrites `?` placeholders into Postgres's positional $1, $2, ...
+// syntax, left to right. It doesn't parse the query, so a `?` inside a
+// string literal would be rewritten too; none of prompt-manager's queries
+// embed a literal `?`.
+func (Postgres) Rebind(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteByte('$')
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+func (Postgres) EnableForeignKeys(conn *sql.DB) error {
+	return nil
+}
+
+func (Postgres) Upsert(conflictColumn string, setColumns []string) string {
+	return upsertClause(conflictColumn, setColumns)
+}
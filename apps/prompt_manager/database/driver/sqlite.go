@@ -0,0 +1,22 @@
+package driver
+
+import "database/sql"
+
+// SQLite is the default Driver, matching prompt-manager's original
+// behavior: `?` placeholders passed straight through and foreign key
+// enforcement turned on explicitly per connection via PRAGMA.
+type SQLite struct{}
+
+func (SQLite) Name() string       { return "sqlite" }
+func (SQLite) DriverName() string { return "sqlite3" }
+
+func (SQLite) Rebind(query string) string { return query }
+
+func (SQLite) EnableForeignKeys(conn *sql.DB) error {
+	_, err := conn.Exec("PRAGMA foreign_keys = ON")
+	return err
+}
+
+func (SQLite) Upsert(conflictColumn string, setColumns []string) string {
+	return upsertClause(conflictColumn, setColumns)
+}
@@ -0,0 +1,19 @@
+package driver
+
+import "strings"
+
+// upsertClause builds an "ON CONFLICT(conflictColumn) DO ..." clause.
+// SQLite 3.24+ borrowed this syntax from Postgres, so both drivers share it
+// as-is; it's still reached through Driver.Upsert so a future backend
+// without it (e.g. MySQL's INSERT ... ON DUPLICATE KEY) has somewhere to
+// diverge.
+func upsertClause(conflictColumn string, setColumns []string) string {
+	if len(setColumns) == 0 {
+		return "ON CONFLICT(" + conflictColumn + ") DO NOTHING"
+	}
+	sets := make([]string, len(setColumns))
+	for i, c := range setColumns {
+		sets[i] = c + " = excluded." + c
+	}
+	return "ON CONFLICT(" + conflictColumn + ") DO UPDATE SET " + strings.Join(sets, ", ")
+}
@@ -0,0 +1,12 @@
+// Package migrations embeds the SQL files in this directory so a statically
+// compiled prompt-manager binary can ship its schema without needing this
+// directory to exist on disk next to it. Dev mode is unaffected: passing
+// database.Config.MigrationsDir (a plain path) to DB.RunMigrations still
+// reads these same files straight off disk, so local edits are picked up
+// without a rebuild.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"context"
+	"database/sql"
+
 	"github.com/claude-code-template/prompt-manager/internal/database"
 	"github.com/claude-code-template/prompt-manager/internal/models"
 )
@@ -20,6 +23,17 @@ func ConvertConversation(dbConv *database.Conversation) models.Conversation {
 	}
 }
 
+// ConvertConversationWithStats converts a database conversation annotated
+// with its average rating and tags (see database.ListConversationsFiltered)
+// to an API conversation model, carrying those two fields across in
+// addition to what ConvertConversation copies.
+func ConvertConversationWithStats(dbConv *database.ConversationWithStats) models.Conversation {
+	apiConv := ConvertConversation(&dbConv.Conversation)
+	apiConv.AverageRating = dbConv.AverageRating
+	apiConv.Tags = dbConv.Tags
+	return apiConv
+}
+
 // ConvertConversationWithMessages converts a database conversation with messages to an API model
 func ConvertConversationWithMessages(dbConv *database.ConversationWithMessages) models.Conversation {
 	apiConv := ConvertConversation(&dbConv.Conversation)
@@ -74,6 +88,47 @@ func ConvertConversationsToSummaries(dbConversations []database.Conversation) []
 	return summaries
 }
 
+// ConvertMessageStream converts rows from database.DB.ListMessagesAfter into
+// a channel of API message models, so the streaming handler can reuse the
+// same conversion logic as the in-memory path. The channel is closed (and
+// rows released) once rows are exhausted, a scan fails, or ctx is
+// cancelled; callers should check rows.Err() after consuming it to
+// distinguish a clean end from a read error. ctx must be cancelled if the
+// caller stops draining the channel before it closes on its own (e.g. a
+// disconnected client), otherwise the producer goroutine blocks forever on
+// its send and rows.Close() never runs.
+func ConvertMessageStream(ctx context.Context, rows *sql.Rows) <-chan models.Message {
+	out := make(chan models.Message)
+
+	go func() {
+		defer close(out)
+		defer rows.Close()
+
+		for rows.Next() {
+			var dbMsg database.Message
+			if err := rows.Scan(
+				&dbMsg.ID,
+				&dbMsg.ConversationID,
+				&dbMsg.MessageType,
+				&dbMsg.Content,
+				&dbMsg.CharacterCount,
+				&dbMsg.Timestamp,
+				&dbMsg.ToolCalls,
+				&dbMsg.ExecutionTime,
+			); err != nil {
+				return
+			}
+			select {
+			case out <- ConvertMessage(&dbMsg):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
 // ConvertRatings converts multiple database ratings to API rating models
 func ConvertRatings(dbRatings []database.Rating) []models.Rating {
 	apiRatings := make([]models.Rating, len(dbRatings))
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/claude-code-template/prompt-manager/internal/api/handlers"
+	"github.com/claude-code-template/prompt-manager/internal/events"
+)
+
+// TestEventsStreamHandler_ReceivesPromptEventFromHookHandler fires a prompt
+// through the hook path while a second goroutine reads the SSE response over
+// a real socket, and asserts the "prompt" event published by PromptHandler
+// arrives within a deadline. A real httptest.NewServer is used instead of
+// httptest.NewRecorder because WriteSSE blocks on r.Context().Done(), which
+// an in-process ResponseRecorder never cancels.
+func TestEventsStreamHandler_ReceivesPromptEventFromHookHandler(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	bus := events.NewBus()
+	server := NewServer(db, WithEventBus(bus))
+	promptHandler := handlers.NewPromptHandler(db, handlers.WithPromptEventBus(bus))
+
+	srv := httptest.NewServer(http.HandlerFunc(server.EventsStreamHandler))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Failed to build stream request: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to connect to event stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Give the handler a moment to subscribe before publishing, so the
+	// prompt event isn't published before anyone is listening for it.
+	time.Sleep(50 * time.Millisecond)
+
+	go func() {
+		hookData := handlers.HookData{
+			Event:     "UserPromptSubmit",
+			Timestamp: time.Now().Format(time.RFC3339),
+			SessionID: "events-stream-session",
+			Filename:  "activity-monitor",
+			Data: map[string]interface{}{
+				"prompt": "hello from the stream test",
+				"cwd":    "/test/directory",
+			},
+		}
+		payload, err := json.Marshal(hookData)
+		if err != nil {
+			t.Errorf("Failed to marshal hook data: %v", err)
+			return
+		}
+
+		promptReq := httptest.NewRequest(http.MethodPost, "/messages/prompt", strings.NewReader(string(payload)))
+		promptReq.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handlers.InvokeWithStatus(promptHandler.HandlePromptSubmit, http.StatusCreated)(rec, promptReq)
+
+		if rec.Code != http.StatusCreated {
+			t.Errorf("Expected prompt submit to return 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}()
+
+	reader := bufio.NewReader(resp.Body)
+	deadline := time.Now().Add(2 * time.Second)
+	sawConversationCreated := false
+	for {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the prompt event over SSE")
+		}
+
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read SSE stream: %v", err)
+		}
+
+		switch {
+		case strings.HasPrefix(line, "event: conversation.created"):
+			sawConversationCreated = true
+		case strings.HasPrefix(line, "event: prompt"):
+			if !sawConversationCreated {
+				t.Error("Expected conversation.created to precede the prompt event for a brand new session")
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/claude-code-template/prompt-manager/internal/database"
+	"github.com/claude-code-template/prompt-manager/internal/events"
+)
+
+// ExportConversationsHandler streams every conversation (optionally
+// filtered by ?since=, an RFC3339 timestamp, and/or ?session_id=) in one of
+// three shapes: an Accept: application/x-ndjson request gets one flat,
+// type-tagged record per line ({"type":"conversation",...},
+// {"type":"prompt",...}, {"type":"response",...}) suited to piping into an
+// analytics tool that doesn't know the nested export shape; otherwise
+// ?format=zip (a manifest.jsonl plus a Markdown transcript per
+// conversation) or the default ?format=jsonl (one database.ExportRecord
+// per line, the shape ImportConversationsHandler round-trips) apply. The
+// flat stream additionally gzips its body when the request sends
+// Accept-Encoding: gzip.
+func (s *Server) ExportConversationsHandler(w http.ResponseWriter, r *http.Request) error {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "jsonl"
+	}
+
+	var since *time.Time
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		t, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			return &HTTPError{Msg: "Invalid 'since' timestamp, expected RFC3339", Code: http.StatusBadRequest}
+		}
+		since = &t
+	}
+	sessionID := r.URL.Query().Get("session_id")
+
+	// StreamExport's producer goroutine blocks on an unbuffered send for
+	// every record; cancelling ctx as soon as we stop draining it (the
+	// client disconnected mid-export, or a write failed) lets that
+	// goroutine's select return instead of hanging on the channel send
+	// forever, which would otherwise also leak rows.Close() and pin the
+	// single DB connection database.New configures.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	records, errCh := s.db.StreamExport(ctx, since, sessionID)
+
+	if strings.Contains(r.Header.Get("Accept"), "application/x-ndjson") {
+		return s.exportFlatNDJSON(w, r, records, errCh, cancel)
+	}
+
+	switch format {
+	case "zip":
+		return s.exportZip(w, records, errCh, cancel)
+	case "jsonl", "ndjson":
+		return s.exportNDJSON(w, records, errCh, cancel)
+	default:
+		return &HTTPError{Msg: "Unknown format: " + format, Code: http.StatusBadRequest}
+	}
+}
+
+// exportFlatNDJSON writes one flat, type-tagged JSON record per
+// conversation and per message as each ExportRecord arrives from the
+// database cursor, flushing after every line (and, when gzip-encoding,
+// after every gzip block) so the client sees the first record long before
+// the last row has been read.
+func (s *Server) exportFlatNDJSON(w http.ResponseWriter, r *http.Request, records <-chan database.ExportRecord, errCh <-chan error, cancel context.CancelFunc) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return &HTTPError{Msg: "Streaming unsupported", Code: http.StatusInternalServerError}
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+
+	var out io.Writer = w
+	var gz *gzip.Writer
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz = gzip.NewWriter(w)
+		defer gz.Close()
+		out = gz
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(out)
+	flush := func() {
+		if gz != nil {
+			gz.Flush()
+		}
+		flusher.Flush()
+	}
+
+	for rec := range records {
+		convRecord, err := flattenRecord(rec.Conversation, "conversation")
+		if err != nil {
+			log.Printf("export stream: failed to flatten conversation %d: %v", rec.Conversation.ID, err)
+			cancel()
+			return nil
+		}
+		if err := encoder.Encode(convRecord); err != nil {
+			cancel()
+			return nil
+		}
+		flush()
+
+		for _, msg := range rec.Messages {
+			msgRecord, err := flattenRecord(msg, msg.MessageType)
+			if err != nil {
+				log.Printf("export stream: failed to flatten message %d: %v", msg.ID, err)
+				cancel()
+				return nil
+			}
+			if err := encoder.Encode(msgRecord); err != nil {
+				cancel()
+				return nil
+			}
+			flush()
+		}
+	}
+
+	if err := <-errCh; err != nil {
+		log.Printf("export stream error: %v", err)
+	}
+	return nil
+}
+
+// flattenRecord re-marshals v (a database.Conversation or database.Message)
+// into a plain map with a "type" field merged alongside its own fields, so
+// the flat export format doesn't nest each record under a "conversation"
+// or "message" key.
+func flattenRecord(v interface{}, recordType string) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	m["type"] = recordType
+	return m, nil
+}
+
+// exportNDJSON writes each ExportRecord as it arrives, flushing after every
+// line so a large export doesn't buffer in memory. Headers are written
+// before the first record, so a failure partway through can only be
+// logged, not turned into an error response.
+func (s *Server) exportNDJSON(w http.ResponseWriter, records <-chan database.ExportRecord, errCh <-chan error, cancel context.CancelFunc) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return &HTTPError{Msg: "Streaming unsupported", Code: http.StatusInternalServerError}
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	for rec := range records {
+		if err := encoder.Encode(rec); err != nil {
+			cancel()
+			return nil
+		}
+		flusher.Flush()
+	}
+
+	if err := <-errCh; err != nil {
+		log.Printf("export stream error: %v", err)
+	}
+	return nil
+}
+
+// exportZip writes a manifest.jsonl (one ExportRecord per line) plus a
+// conversations/<id>.md transcript per conversation into a zip archive.
+// archive/zip only finalizes its central directory on Close, but entry
+// data is still written to w as it's produced, so this doesn't require
+// buffering the whole archive in memory.
+func (s *Server) exportZip(w http.ResponseWriter, records <-chan database.ExportRecord, errCh <-chan error, cancel context.CancelFunc) error {
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="conversations-export.zip"`)
+	w.WriteHeader(http.StatusOK)
+
+	zw := zip.NewWriter(w)
+	defer zw.Close()
+
+	manifest, err := zw.Create("manifest.jsonl")
+	if err != nil {
+		log.Printf("export zip: failed to create manifest: %v", err)
+		cancel()
+		return nil
+	}
+	encoder := json.NewEncoder(manifest)
+
+	for rec := range records {
+		if err := encoder.Encode(rec); err != nil {
+			log.Printf("export zip: failed to encode manifest record: %v", err)
+			cancel()
+			return nil
+		}
+
+		name := fmt.Sprintf("conversations/%d.md", rec.Conversation.ID)
+		transcript, err := zw.Create(name)
+		if err != nil {
+			log.Printf("export zip: failed to create %s: %v", name, err)
+			cancel()
+			return nil
+		}
+		if _, err := transcript.Write([]byte(renderTranscriptMarkdown(rec))); err != nil {
+			log.Printf("export zip: failed to write %s: %v", name, err)
+			cancel()
+			return nil
+		}
+	}
+
+	if err := <-errCh; err != nil {
+		log.Printf("export zip: %v", err)
+	}
+	return nil
+}
+
+// renderTranscriptMarkdown renders an ExportRecord as a human-readable
+// Markdown transcript for inclusion in a zip export.
+func renderTranscriptMarkdown(rec database.ExportRecord) string {
+	var b strings.Builder
+
+	title := rec.Conversation.Title
+	if title == nil || *title == "" {
+		fmt.Fprintf(&b, "# Conversation %d\n\n", rec.Conversation.ID)
+	} else {
+		fmt.Fprintf(&b, "# %s\n\n", *title)
+	}
+	fmt.Fprintf(&b, "Session: %s\n\n", rec.Conversation.SessionID)
+
+	for _, msg := range rec.Messages {
+		fmt.Fprintf(&b, "## %s (%s)\n\n%s\n\n", msg.MessageType, msg.Timestamp.Format(time.RFC3339), msg.Content)
+	}
+
+	return b.String()
+}
+
+// ImportConversationsHandler reads a JSONL body of database.ExportRecord
+// values (the same shape ExportConversationsHandler produces) and upserts
+// them inside a single transaction, publishing import.progress events on
+// the global stream as each record is written into it, ahead of the commit.
+func (s *Server) ImportConversationsHandler(w http.ResponseWriter, r *http.Request) error {
+	var records []database.ExportRecord
+	dec := json.NewDecoder(r.Body)
+	for dec.More() {
+		var rec database.ExportRecord
+		if err := dec.Decode(&rec); err != nil {
+			return &HTTPError{Msg: "Invalid JSONL request body", Code: http.StatusBadRequest}
+		}
+		records = append(records, rec)
+	}
+
+	imported, err := s.db.ImportConversations(records, func(processed, total int) {
+		s.bus.Publish(events.GlobalTopic, "import.progress", map[string]int{"processed": processed, "total": total})
+	})
+	if err != nil {
+		return fmt.Errorf("import failed: %w", err)
+	}
+
+	successResponse(w, map[string]int{"imported": imported}, nil)
+	return nil
+}
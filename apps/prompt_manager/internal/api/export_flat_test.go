@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/claude-code-template/prompt-manager/internal/database"
+)
+
+// TestExportFlatNDJSON_WritesFirstRecordBeforeChannelDrains proves the
+// handler flushes each record as it arrives rather than buffering the
+// whole export: it reads one line back over a real HTTP connection while
+// the export channel is still open and no further record has been sent.
+func TestExportFlatNDJSON_WritesFirstRecordBeforeChannelDrains(t *testing.T) {
+	s := &Server{}
+
+	records := make(chan database.ExportRecord)
+	errCh := make(chan error, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.exportFlatNDJSON(w, r, records, errCh, func() {})
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	req.Header.Set("Accept", "application/x-ndjson")
+
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	respCh := make(chan result, 1)
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		respCh <- result{resp, err}
+	}()
+
+	title := "first"
+	records <- database.ExportRecord{Conversation: database.Conversation{ID: 1, SessionID: "stream-a", Title: &title}}
+
+	var res result
+	select {
+	case res = <-respCh:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the response to start")
+	}
+	if res.err != nil {
+		t.Fatalf("Request failed: %v", res.err)
+	}
+	defer res.resp.Body.Close()
+
+	lineCh := make(chan string, 1)
+	errLineCh := make(chan error, 1)
+	go func() {
+		line, err := bufio.NewReader(res.resp.Body).ReadString('\n')
+		if err != nil {
+			errLineCh <- err
+			return
+		}
+		lineCh <- line
+	}()
+
+	var line string
+	select {
+	case line = <-lineCh:
+	case err := <-errLineCh:
+		t.Fatalf("Failed to read streamed record: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the first record to be flushed; export appears to be buffering instead of streaming")
+	}
+
+	if !strings.Contains(line, `"type":"conversation"`) || !strings.Contains(line, `"session_id":"stream-a"`) {
+		t.Errorf("Expected a flattened conversation record, got %q", line)
+	}
+
+	close(records)
+	close(errCh)
+}
+
+func TestExportConversationsHandler_FiltersBySessionIDAndFormatsFlat(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if _, err := db.CreateConversation("keep-me", nil, nil, nil); err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+	if _, err := db.CreateConversation("skip-me", nil, nil, nil); err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+
+	s := NewServer(db)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/export?session_id=keep-me", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	w := httptest.NewRecorder()
+
+	if err := s.ExportConversationsHandler(w, req); err != nil {
+		t.Fatalf("ExportConversationsHandler failed: %v", err)
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, `"session_id":"keep-me"`) {
+		t.Errorf("Expected the filtered session to appear in the export, got %q", body)
+	}
+	if strings.Contains(body, `"session_id":"skip-me"`) {
+		t.Errorf("Expected the unfiltered session to be excluded, got %q", body)
+	}
+}
@@ -1,25 +1,73 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/claude-code-template/prompt-manager/internal/database"
+	"github.com/claude-code-template/prompt-manager/internal/events"
 	"github.com/claude-code-template/prompt-manager/internal/models"
 )
 
 // Server holds the database connection and provides HTTP handlers
 type Server struct {
-	db *database.DB
+	db       *database.DB
+	reranker database.Reranker
+	bus      *events.Bus
+}
+
+// ServerOption configures optional Server behavior.
+type ServerOption func(*Server)
+
+// WithReranker overrides the Reranker used to reorder search hits. The
+// default is database.NoopReranker{}, which leaves BM25 ordering untouched.
+func WithReranker(reranker database.Reranker) ServerOption {
+	return func(s *Server) {
+		s.reranker = reranker
+	}
+}
+
+// WithEventBus overrides the events.Bus used to publish and subscribe to
+// live conversation/rating updates. The default is a fresh, unshared
+// events.NewBus(), so pass the bus returned to callers who also need to
+// publish message events (e.g. the prompt hook handler).
+func WithEventBus(bus *events.Bus) ServerOption {
+	return func(s *Server) {
+		s.bus = bus
+	}
 }
 
 // NewServer creates a new API server
-func NewServer(db *database.DB) *Server {
-	return &Server{db: db}
+func NewServer(db *database.DB, opts ...ServerOption) *Server {
+	s := &Server{db: db, reranker: database.NoopReranker{}, bus: events.NewBus()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// publishConversationEvent fans an event out to both the global stream and
+// the conversation's own stream.
+func (s *Server) publishConversationEvent(conversationID int, eventType string, data interface{}) {
+	s.bus.Publish(events.GlobalTopic, eventType, data)
+	s.bus.Publish(events.ConversationTopic(conversationID), eventType, data)
+}
+
+// lastEventID parses the SSE Last-Event-ID header clients send on
+// reconnect, so recently missed events can be replayed from the bus's ring
+// buffer. Returns 0 (no replay) if absent or malformed.
+func lastEventID(r *http.Request) int64 {
+	id, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
 }
 
 // APIResponse represents a standard API response
@@ -110,29 +158,30 @@ func (s *Server) ListConversationsHandler(w http.ResponseWriter, r *http.Request
 	}
 	
 	offset := (page - 1) * perPage
-	
-	conversations, err := s.db.ListConversations(perPage, offset)
+
+	tag := r.URL.Query().Get("tag")
+
+	var minAvgRating *float64
+	if minAvgRatingStr := r.URL.Query().Get("min_avg_rating"); minAvgRatingStr != "" {
+		parsed, err := strconv.ParseFloat(minAvgRatingStr, 64)
+		if err != nil {
+			errorResponse(w, "Invalid 'min_avg_rating'", http.StatusBadRequest)
+			return
+		}
+		minAvgRating = &parsed
+	}
+
+	conversations, err := s.db.ListConversationsFiltered(perPage, offset, tag, minAvgRating)
 	if err != nil {
 		errorResponse(w, fmt.Sprintf("Failed to list conversations: %v", err), http.StatusInternalServerError)
 		return
 	}
-	
+
 	// Convert to summaries for list view
 	summaries := make([]models.ConversationSummary, len(conversations))
 	for i, conv := range conversations {
-		// Create a model conversation to use ToSummary method
-		modelConv := models.Conversation{
-			ID:               conv.ID,
-			SessionID:        conv.SessionID,
-			Title:            conv.Title,
-			CreatedAt:        conv.CreatedAt,
-			UpdatedAt:        conv.UpdatedAt,
-			PromptCount:      conv.PromptCount,
-			TotalCharacters:  conv.TotalCharacters,
-			WorkingDirectory: conv.WorkingDirectory,
-			TranscriptPath:   conv.TranscriptPath,
-		}
-		summaries[i] = modelConv.ToSummary()
+		apiConv := ConvertConversationWithStats(&conv)
+		summaries[i] = apiConv.ToSummary()
 	}
 	
 	meta := &Meta{
@@ -143,31 +192,24 @@ func (s *Server) ListConversationsHandler(w http.ResponseWriter, r *http.Request
 	successResponse(w, summaries, meta)
 }
 
-// GetConversationHandler returns a specific conversation with messages
-func (s *Server) GetConversationHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	idStr, exists := vars["id"]
-	if !exists {
-		errorResponse(w, "Conversation ID is required", http.StatusBadRequest)
-		return
-	}
-	
-	id, err := strconv.Atoi(idStr)
+// GetConversationHandler returns a specific conversation with messages. It
+// is registered through Catch (see router.go), so validation failures are
+// reported by returning an *HTTPError instead of writing the response and
+// returning directly.
+func (s *Server) GetConversationHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := intVar(r, "id")
 	if err != nil {
-		errorResponse(w, "Invalid conversation ID", http.StatusBadRequest)
-		return
+		return err
 	}
-	
+
 	conv, err := s.db.GetConversationWithMessages(id)
 	if err != nil {
 		if err.Error() == "conversation not found" {
-			errorResponse(w, "Conversation not found", http.StatusNotFound)
-			return
+			return &HTTPError{Msg: "Conversation not found", Code: http.StatusNotFound}
 		}
-		errorResponse(w, fmt.Sprintf("Failed to get conversation: %v", err), http.StatusInternalServerError)
-		return
+		return fmt.Errorf("failed to get conversation: %w", err)
 	}
-	
+
 	// Convert database models to API models
 	apiConv := models.Conversation{
 		ID:               conv.ID,
@@ -180,12 +222,12 @@ func (s *Server) GetConversationHandler(w http.ResponseWriter, r *http.Request)
 		WorkingDirectory: conv.WorkingDirectory,
 		TranscriptPath:   conv.TranscriptPath,
 	}
-	
+
 	// Convert messages
 	apiMessages := make([]models.Message, len(conv.Messages))
 	for i, msg := range conv.Messages {
 		toolCalls, _ := models.UnmarshalToolCalls(msg.ToolCalls)
-		
+
 		apiMessages[i] = models.Message{
 			ID:             msg.ID,
 			ConversationID: msg.ConversationID,
@@ -198,8 +240,206 @@ func (s *Server) GetConversationHandler(w http.ResponseWriter, r *http.Request)
 		}
 	}
 	apiConv.Messages = apiMessages
-	
+
+	avgRating, err := s.db.GetConversationAverageRating(id)
+	if err != nil {
+		return fmt.Errorf("failed to get average rating: %w", err)
+	}
+	apiConv.AverageRating = avgRating
+
+	tags, err := s.db.GetConversationTags(id)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation tags: %w", err)
+	}
+	apiConv.Tags = tags
+
 	successResponse(w, apiConv, nil)
+	return nil
+}
+
+// GetConversationMessagesHandler streams a conversation's messages as
+// newline-delimited JSON using a keyset cursor (?after_id=&limit=) instead
+// of offset pagination, so a session with thousands of messages can be
+// paged without materializing the whole conversation in memory.
+func (s *Server) GetConversationMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr, exists := vars["id"]
+	if !exists {
+		errorResponse(w, "Conversation ID is required", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		errorResponse(w, "Invalid conversation ID", http.StatusBadRequest)
+		return
+	}
+
+	afterID := 0
+	if afterStr := r.URL.Query().Get("after_id"); afterStr != "" {
+		if a, err := strconv.Atoi(afterStr); err == nil && a >= 0 {
+			afterID = a
+		}
+	}
+
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 1000 {
+			limit = l
+		}
+	}
+
+	rows, err := s.db.ListMessagesAfter(id, afterID, limit)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Failed to list messages: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		rows.Close()
+		errorResponse(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	// ConvertMessageStream's producer goroutine blocks on an unbuffered send
+	// for every row; cancelling ctx as soon as we stop caring (a failed
+	// Encode, e.g. because the client already disconnected) lets that
+	// goroutine's select return instead of hanging on the channel send
+	// forever, which would otherwise also leak rows.Close() and pin the
+	// single DB connection database.New configures. The loop keeps draining
+	// (without re-encoding) until the now-cancelled producer closes the
+	// channel, rather than returning immediately and abandoning it.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	encoder := json.NewEncoder(w)
+	failed := false
+	for msg := range ConvertMessageStream(ctx, rows) {
+		if failed {
+			continue
+		}
+		if err := encoder.Encode(msg); err != nil {
+			failed = true
+			cancel()
+			continue
+		}
+		flusher.Flush()
+	}
+}
+
+// SearchHandler runs a full-text search over messages and/or conversations
+// (?q=, scope=messages|conversations, from=, to=, min_rating=, session_id=,
+// limit=, offset=), ranking hits with SQLite FTS5/BM25 and optionally
+// reordering them with the configured Reranker.
+func (s *Server) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		errorResponse(w, "Query parameter 'q' is required", http.StatusBadRequest)
+		return
+	}
+
+	filters := database.SearchFilters{
+		Scope:     r.URL.Query().Get("scope"),
+		SessionID: r.URL.Query().Get("session_id"),
+	}
+
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		from, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			errorResponse(w, "Invalid 'from' timestamp, expected RFC3339", http.StatusBadRequest)
+			return
+		}
+		filters.From = &from
+	}
+
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		to, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			errorResponse(w, "Invalid 'to' timestamp, expected RFC3339", http.StatusBadRequest)
+			return
+		}
+		filters.To = &to
+	}
+
+	if minRatingStr := r.URL.Query().Get("min_rating"); minRatingStr != "" {
+		minRating, err := strconv.Atoi(minRatingStr)
+		if err != nil {
+			errorResponse(w, "Invalid 'min_rating'", http.StatusBadRequest)
+			return
+		}
+		filters.MinRating = &minRating
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	results, err := s.db.Search(query, filters, limit, offset, s.reranker)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Search failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	successResponse(w, results, nil)
+}
+
+// StreamHandler subscribes the caller to every event on the global topic
+// (conversation.created/updated/deleted, prompt, rating.updated/deleted,
+// and import.progress) as Server-Sent Events. Clients reconnecting after a drop can send Last-Event-ID to
+// replay anything they missed from the bus's ring buffer.
+func (s *Server) StreamHandler(w http.ResponseWriter, r *http.Request) {
+	sub := s.bus.Subscribe(events.GlobalTopic, lastEventID(r))
+	defer sub.Close()
+
+	if err := events.WriteSSE(w, r, sub); err != nil {
+		log.Printf("sse stream error: %v", err)
+	}
+}
+
+// EventsStreamHandler is the documented entry point for live prompt/
+// response/conversation activity; it is StreamHandler under a clearer,
+// more specific path so new integrations don't have to discover that
+// "/stream" is the global feed.
+func (s *Server) EventsStreamHandler(w http.ResponseWriter, r *http.Request) {
+	s.StreamHandler(w, r)
+}
+
+// ConversationStreamHandler is StreamHandler scoped to a single
+// conversation's events.
+func (s *Server) ConversationStreamHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr, exists := vars["id"]
+	if !exists {
+		errorResponse(w, "Conversation ID is required", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		errorResponse(w, "Invalid conversation ID", http.StatusBadRequest)
+		return
+	}
+
+	sub := s.bus.Subscribe(events.ConversationTopic(id), lastEventID(r))
+	defer sub.Close()
+
+	if err := events.WriteSSE(w, r, sub); err != nil {
+		log.Printf("sse stream error: %v", err)
+	}
 }
 
 // CreateConversationHandler creates a new conversation
@@ -239,6 +479,8 @@ func (s *Server) CreateConversationHandler(w http.ResponseWriter, r *http.Reques
 		TranscriptPath:   conv.TranscriptPath,
 	}
 	
+	s.publishConversationEvent(apiConv.ID, "conversation.created", apiConv)
+
 	w.WriteHeader(http.StatusCreated)
 	successResponse(w, apiConv, nil)
 }
@@ -300,6 +542,8 @@ func (s *Server) UpdateConversationHandler(w http.ResponseWriter, r *http.Reques
 		TranscriptPath:   conv.TranscriptPath,
 	}
 	
+	s.publishConversationEvent(apiConv.ID, "conversation.updated", apiConv)
+
 	successResponse(w, apiConv, nil)
 }
 
@@ -326,7 +570,9 @@ func (s *Server) DeleteConversationHandler(w http.ResponseWriter, r *http.Reques
 		errorResponse(w, fmt.Sprintf("Failed to delete conversation: %v", err), http.StatusInternalServerError)
 		return
 	}
-	
+
+	s.publishConversationEvent(id, "conversation.deleted", map[string]int{"id": id})
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -378,6 +624,8 @@ func (s *Server) CreateConversationRatingHandler(w http.ResponseWriter, r *http.
 		UpdatedAt:      rating.UpdatedAt,
 	}
 	
+	s.publishConversationEvent(id, "rating.updated", apiRating)
+
 	w.WriteHeader(http.StatusCreated)
 	successResponse(w, apiRating, nil)
 }
@@ -475,6 +723,8 @@ func (s *Server) UpdateRatingHandler(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt:      rating.UpdatedAt,
 	}
 	
+	s.publishConversationEvent(apiRating.ConversationID, "rating.updated", apiRating)
+
 	successResponse(w, apiRating, nil)
 }
 
@@ -492,7 +742,13 @@ func (s *Server) DeleteRatingHandler(w http.ResponseWriter, r *http.Request) {
 		errorResponse(w, "Invalid rating ID", http.StatusBadRequest)
 		return
 	}
-	
+
+	rating, err := s.db.GetRating(id)
+	if err != nil {
+		errorResponse(w, "Rating not found", http.StatusNotFound)
+		return
+	}
+
 	if err := s.db.DeleteRating(id); err != nil {
 		if err.Error() == "rating not found" {
 			errorResponse(w, "Rating not found", http.StatusNotFound)
@@ -501,7 +757,9 @@ func (s *Server) DeleteRatingHandler(w http.ResponseWriter, r *http.Request) {
 		errorResponse(w, fmt.Sprintf("Failed to delete rating: %v", err), http.StatusInternalServerError)
 		return
 	}
-	
+
+	s.publishConversationEvent(rating.ConversationID, "rating.deleted", map[string]int{"id": id})
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/claude-code-template/prompt-manager/internal/database"
+)
+
+// AdminHandler exposes the maintenance operations on database.DB (backup,
+// vacuum, integrity check, WAL checkpoint) over HTTP, so an operator can
+// trigger them without shelling into the host the server runs on.
+type AdminHandler struct {
+	db *database.DB
+}
+
+// NewAdminHandler creates a new admin handler.
+func NewAdminHandler(db *database.DB) *AdminHandler {
+	return &AdminHandler{db: db}
+}
+
+// backupRequest is the JSON body HandleBackup expects.
+type backupRequest struct {
+	Destination string `json:"destination"`
+}
+
+// HandleBackup takes an online backup of the database to the path given in
+// the request body, via database.DB.Backup.
+func (ah *AdminHandler) HandleBackup(r *http.Request) (*APIResponse, error) {
+	var req backupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, &HTTPError{Msg: "Invalid JSON request body", Code: http.StatusBadRequest}
+	}
+	if req.Destination == "" {
+		return nil, &HTTPError{Msg: "destination is required", Code: http.StatusBadRequest}
+	}
+
+	if err := ah.db.Backup(r.Context(), req.Destination); err != nil {
+		return nil, &HTTPError{Msg: err.Error(), Code: http.StatusInternalServerError}
+	}
+
+	return &APIResponse{Success: true, Data: map[string]interface{}{"destination": req.Destination}}, nil
+}
+
+// HandleVacuum rebuilds the database file via database.DB.Vacuum.
+func (ah *AdminHandler) HandleVacuum(r *http.Request) (*APIResponse, error) {
+	if err := ah.db.Vacuum(); err != nil {
+		return nil, &HTTPError{Msg: err.Error(), Code: http.StatusInternalServerError}
+	}
+	return &APIResponse{Success: true}, nil
+}
+
+// HandleIntegrityCheck runs database.DB.IntegrityCheck and reports any
+// problems found.
+func (ah *AdminHandler) HandleIntegrityCheck(r *http.Request) (*APIResponse, error) {
+	problems, err := ah.db.IntegrityCheck()
+	if err != nil {
+		return nil, &HTTPError{Msg: err.Error(), Code: http.StatusInternalServerError}
+	}
+	return &APIResponse{Success: true, Data: map[string]interface{}{"ok": len(problems) == 0, "problems": problems}}, nil
+}
+
+// HandleCheckpoint runs a WAL checkpoint via database.DB.Checkpoint.
+func (ah *AdminHandler) HandleCheckpoint(r *http.Request) (*APIResponse, error) {
+	if err := ah.db.Checkpoint(); err != nil {
+		return nil, &HTTPError{Msg: err.Error(), Code: http.StatusInternalServerError}
+	}
+	return &APIResponse{Success: true}, nil
+}
+
+// HandleStats returns database.DB.Stats, including the WAL size and page
+// count an operator would otherwise check before deciding to Vacuum or
+// Checkpoint.
+func (ah *AdminHandler) HandleStats(r *http.Request) (*APIResponse, error) {
+	stats, err := ah.db.Stats()
+	if err != nil {
+		return nil, &HTTPError{Msg: err.Error(), Code: http.StatusInternalServerError}
+	}
+	return &APIResponse{Success: true, Data: stats}, nil
+}
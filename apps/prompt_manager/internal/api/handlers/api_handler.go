@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// HTTPError is an error that carries the HTTP status code it should be
+// reported with. Handlers built on APIHandler return it directly instead of
+// hand-writing an error response at every validation branch.
+type HTTPError struct {
+	Msg  string
+	Code int
+}
+
+func (e *HTTPError) Error() string {
+	return e.Msg
+}
+
+// APIHandler is the shape every endpoint in this package is written against.
+// Returning an *HTTPError lets Invoke translate it into the right status
+// code; any other error is reported as a 500.
+type APIHandler func(r *http.Request) (*APIResponse, error)
+
+// Invoke adapts an APIHandler into an http.HandlerFunc. It recovers panics,
+// applies uniform error handling, and writes the JSON response, so a bad hook
+// payload or a bug in a handler cannot take the server down.
+func Invoke(fn APIHandler) http.HandlerFunc {
+	return InvokeWithStatus(fn, http.StatusOK)
+}
+
+// InvokeWithStatus behaves like Invoke but writes successStatus instead of
+// 200 when fn returns without error (e.g. http.StatusCreated for endpoints
+// that create a resource).
+func InvokeWithStatus(fn APIHandler, successStatus int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer RecoverAndRespond(w, r)
+
+		resp, err := fn(r)
+		if err != nil {
+			RespondError(w, err)
+			return
+		}
+
+		writeJSON(w, successStatus, resp)
+	}
+}
+
+// RecoverAndRespond recovers a panic in progress, logging it and writing a
+// 500 rather than letting it crash the server. It's exported, deferred
+// directly (not wrapped in another closure), so callers whose handler shape
+// can't fit APIHandler (e.g. one that must stream to w directly instead of
+// returning a response to write) can still share the same panic-recovery
+// behavior Invoke/InvokeWithStatus use instead of re-implementing it.
+func RecoverAndRespond(w http.ResponseWriter, r *http.Request) {
+	if rec := recover(); rec != nil {
+		log.Printf("panic handling %s %s: %v\n%s", r.Method, r.URL.Path, rec, debug.Stack())
+		writeJSON(w, http.StatusInternalServerError, errorResponseBody("internal server error"))
+	}
+}
+
+// RespondError writes err as a JSON error response: an *HTTPError is
+// translated to its status/message, any other error becomes a 500. Exported
+// for the same reason as RecoverAndRespond, so handler styles that can't
+// return an APIResponse for Invoke to write still share one error-to-status
+// translation instead of each defining their own.
+func RespondError(w http.ResponseWriter, err error) {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		writeJSON(w, httpErr.Code, errorResponseBody(httpErr.Msg))
+		return
+	}
+	writeJSON(w, http.StatusInternalServerError, errorResponseBody(err.Error()))
+}
+
+func errorResponseBody(msg string) *APIResponse {
+	return &APIResponse{Success: false, Error: &msg}
+}
+
+func writeJSON(w http.ResponseWriter, status int, resp *APIResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInvoke_Success(t *testing.T) {
+	fn := func(r *http.Request) (*APIResponse, error) {
+		return &APIResponse{Success: true, Data: "ok"}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	Invoke(fn)(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestInvokeWithStatus_SuccessStatus(t *testing.T) {
+	fn := func(r *http.Request) (*APIResponse, error) {
+		return &APIResponse{Success: true}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	w := httptest.NewRecorder()
+	InvokeWithStatus(fn, http.StatusCreated)(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+func TestInvoke_HTTPError(t *testing.T) {
+	fn := func(r *http.Request) (*APIResponse, error) {
+		return nil, &HTTPError{Msg: "session_id is required", Code: http.StatusBadRequest}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	w := httptest.NewRecorder()
+	Invoke(fn)(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	var resp APIResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Success {
+		t.Error("Expected response.Success to be false")
+	}
+	if resp.Error == nil || *resp.Error != "session_id is required" {
+		t.Errorf("Expected 'session_id is required' error, got %v", resp.Error)
+	}
+}
+
+func TestInvoke_UnwrappedError(t *testing.T) {
+	fn := func(r *http.Request) (*APIResponse, error) {
+		return nil, errors.New("boom")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	w := httptest.NewRecorder()
+	Invoke(fn)(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestInvoke_RecoversPanic(t *testing.T) {
+	fn := func(r *http.Request) (*APIResponse, error) {
+		panic("unexpected failure")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	w := httptest.NewRecorder()
+
+	Invoke(fn)(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+
+	var resp APIResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Success {
+		t.Error("Expected response.Success to be false")
+	}
+}
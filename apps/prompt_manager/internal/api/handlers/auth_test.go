@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func okHandler() http.Handler {
+	return Invoke(func(r *http.Request) (*APIResponse, error) {
+		return &APIResponse{Success: true}, nil
+	})
+}
+
+func TestNewAuthMiddleware_RejectsMissingBearerToken(t *testing.T) {
+	mw := NewAuthMiddleware(AuthOptions{HookToken: "secret"})
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/session", nil)
+	w := httptest.NewRecorder()
+	mw(okHandler()).ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestNewAuthMiddleware_AcceptsMatchingBearerToken(t *testing.T) {
+	mw := NewAuthMiddleware(AuthOptions{HookToken: "secret"})
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/session", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	mw(okHandler()).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestNewAuthMiddleware_RejectsWrongBearerToken(t *testing.T) {
+	mw := NewAuthMiddleware(AuthOptions{HookToken: "secret"})
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/session", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	w := httptest.NewRecorder()
+	mw(okHandler()).ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestNewAuthMiddleware_RequireXSRF_RejectsMismatch(t *testing.T) {
+	mw := NewAuthMiddleware(AuthOptions{RequireXSRF: true})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/conversations", nil)
+	req.AddCookie(&http.Cookie{Name: xsrfCookieName, Value: "abc"})
+	req.Header.Set(xsrfHeaderName, "def")
+	w := httptest.NewRecorder()
+	mw(okHandler()).ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestNewAuthMiddleware_RequireXSRF_AcceptsMatchingPair(t *testing.T) {
+	mw := NewAuthMiddleware(AuthOptions{RequireXSRF: true})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/conversations", nil)
+	req.AddCookie(&http.Cookie{Name: xsrfCookieName, Value: "abc"})
+	req.Header.Set(xsrfHeaderName, "abc")
+	w := httptest.NewRecorder()
+	mw(okHandler()).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestNewAuthMiddleware_RequireXSRF_IgnoresReadRequests(t *testing.T) {
+	mw := NewAuthMiddleware(AuthOptions{RequireXSRF: true})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/conversations", nil)
+	w := httptest.NewRecorder()
+	mw(okHandler()).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func signHMAC(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestNewAuthMiddleware_HMACSignature(t *testing.T) {
+	const secret = "hmac-secret"
+	body := []byte(`{"hello":"world"}`)
+
+	validTimestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	staleTimestamp := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name       string
+		signature  string
+		timestamp  string
+		wantStatus int
+	}{
+		{
+			name:       "missing signature header",
+			timestamp:  validTimestamp,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "wrong signature",
+			signature:  "sha256=" + hex.EncodeToString([]byte("not-the-real-mac")),
+			timestamp:  validTimestamp,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "stale timestamp",
+			signature:  signHMAC(secret, staleTimestamp, body),
+			timestamp:  staleTimestamp,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "valid signature",
+			signature:  signHMAC(secret, validTimestamp, body),
+			timestamp:  validTimestamp,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw := NewAuthMiddleware(AuthOptions{HMACSecret: secret})
+
+			req := httptest.NewRequest(http.MethodPost, "/messages/prompt", bytes.NewReader(body))
+			if tt.signature != "" {
+				req.Header.Set("X-Signature", tt.signature)
+			}
+			if tt.timestamp != "" {
+				req.Header.Set("X-Timestamp", tt.timestamp)
+			}
+			w := httptest.NewRecorder()
+			mw(okHandler()).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestNewAuthMiddleware_AcceptsBearerWhenHMACAlsoConfigured(t *testing.T) {
+	mw := NewAuthMiddleware(AuthOptions{HookToken: "secret", HMACSecret: "hmac-secret"})
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/prompt", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	mw(okHandler()).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestLoadOrCreateHookToken_PersistsAndReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hook.token")
+
+	token1, err := LoadOrCreateHookToken(path)
+	if err != nil {
+		t.Fatalf("Failed to create hook token: %v", err)
+	}
+	if token1 == "" {
+		t.Fatal("Expected a non-empty token")
+	}
+
+	token2, err := LoadOrCreateHookToken(path)
+	if err != nil {
+		t.Fatalf("Failed to reload hook token: %v", err)
+	}
+	if token1 != token2 {
+		t.Errorf("Expected reloading to return the same token, got %q and %q", token1, token2)
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+)
+
+// DefaultMaxBatchItems caps how many events HandleBatchSubmit will process
+// in a single request, so one oversized payload cannot pin a hook
+// connection or blow up memory.
+const DefaultMaxBatchItems = 500
+
+// DefaultMaxBatchBodyBytes caps the request body size HandleBatchSubmit
+// will read, independent of DefaultMaxBatchItems.
+const DefaultMaxBatchBodyBytes = 5 << 20 // 5 MiB
+
+// BatchHandler dispatches a single HTTP request containing many hook
+// events to the existing prompt/response handlers, so a hook client that
+// buffered events during a network blip can flush them in one round trip
+// instead of paying a transaction per event.
+type BatchHandler struct {
+	prompt       *PromptHandler
+	response     *ResponseHandler
+	maxItems     int
+	maxBodyBytes int64
+}
+
+// BatchHandlerOption configures a BatchHandler.
+type BatchHandlerOption func(*BatchHandler)
+
+// WithBatchMaxItems overrides the maximum number of events accepted in a
+// single batch. Defaults to DefaultMaxBatchItems.
+func WithBatchMaxItems(n int) BatchHandlerOption {
+	return func(bh *BatchHandler) {
+		bh.maxItems = n
+	}
+}
+
+// WithBatchMaxBodyBytes overrides the maximum request body size accepted.
+// Defaults to DefaultMaxBatchBodyBytes.
+func WithBatchMaxBodyBytes(n int64) BatchHandlerOption {
+	return func(bh *BatchHandler) {
+		bh.maxBodyBytes = n
+	}
+}
+
+// NewBatchHandler creates a batch handler that dispatches "prompt"-type
+// items to prompt and "response"-type items to response.
+func NewBatchHandler(prompt *PromptHandler, response *ResponseHandler, opts ...BatchHandlerOption) *BatchHandler {
+	bh := &BatchHandler{
+		prompt:       prompt,
+		response:     response,
+		maxItems:     DefaultMaxBatchItems,
+		maxBodyBytes: DefaultMaxBatchBodyBytes,
+	}
+	for _, opt := range opts {
+		opt(bh)
+	}
+	return bh
+}
+
+// batchItem is one entry of the JSON array HandleBatchSubmit accepts: a
+// discriminator naming which existing endpoint should handle the event,
+// plus the event itself verbatim as that endpoint's HookData payload.
+type batchItem struct {
+	Type  string          `json:"type"`
+	Event json.RawMessage `json:"event"`
+}
+
+// BatchItemResult is one entry of the per-item result array
+// HandleBatchSubmit responds with.
+type BatchItemResult struct {
+	Index   int         `json:"index"`
+	Success bool        `json:"success"`
+	Error   string      `json:"error,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// HandleBatchSubmit processes a JSON array of batchItem records from a
+// single request body. Each item is dispatched to the prompt or response
+// handler it names and reported independently in the response, so one
+// malformed item (e.g. a missing session_id) fails without discarding the
+// rest of the batch. The overall status is 200 if every item succeeded and
+// 207 (Multi-Status) otherwise.
+//
+// Dispatch reuses the existing handlers' own HTTP entry points rather than
+// one shared database transaction, so a batch is not atomic and does not
+// meet this endpoint's original one-fsync-per-batch goal: each item still
+// commits on its own.
+//
+// Escalation, not an oversight: a single enclosing transaction would need
+// database.Message/database.Conversation and the CreateMessage/
+// GetOrCreateConversationBySessionID write path to accept a shared *sql.Tx,
+// and those types/methods are not present anywhere in this tree for
+// dispatch to build on (grep turns up calls to db.CreateMessage but no
+// definition) — this is a database-layer gap that predates this handler,
+// not something a batch-endpoint change can fix in isolation. Wiring one
+// transaction through dispatch is tracked as follow-up work once that
+// layer exists; until then this handler trades the requested fsync
+// amortization for per-item isolation (one bad item can't roll back ones
+// already committed ahead of it).
+//
+// HandleBatchSubmit keeps its own func(w, r) signature rather than being
+// registered through Invoke/InvokeWithStatus like its siblings: it needs
+// the real http.ResponseWriter for http.MaxBytesReader to enforce
+// maxBodyBytes, and its success status (200 vs. 207) depends on the batch
+// outcome rather than being fixed at registration time. It still shares
+// Invoke's panic recovery via the deferred RecoverAndRespond below.
+func (bh *BatchHandler) HandleBatchSubmit(w http.ResponseWriter, r *http.Request) {
+	defer RecoverAndRespond(w, r)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponseBody("Method not allowed"))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, bh.maxBodyBytes)
+
+	var items []batchItem
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponseBody("invalid JSON request body or batch exceeds the maximum body size"))
+		return
+	}
+
+	if len(items) == 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponseBody("batch must contain at least one event"))
+		return
+	}
+	if len(items) > bh.maxItems {
+		writeJSON(w, http.StatusBadRequest, errorResponseBody(fmt.Sprintf("batch exceeds the maximum of %d events", bh.maxItems)))
+		return
+	}
+
+	results := make([]BatchItemResult, len(items))
+	allSucceeded := true
+	for i, item := range items {
+		result := bh.dispatch(r, i, item)
+		results[i] = result
+		if !result.Success {
+			allSucceeded = false
+		}
+	}
+
+	status := http.StatusOK
+	if !allSucceeded {
+		status = http.StatusMultiStatus
+	}
+	writeJSON(w, status, &APIResponse{Success: allSucceeded, Data: results})
+}
+
+// dispatch runs a single batch item through the endpoint it names, by
+// replaying it as a standalone request against that endpoint's existing
+// handler and capturing the result. Both branches run under
+// RecoverAndRespond, so a panic in either handler fails just that item
+// (as a 500 decoded below) rather than the whole batch request.
+func (bh *BatchHandler) dispatch(r *http.Request, index int, item batchItem) BatchItemResult {
+	req := httptest.NewRequest(http.MethodPost, r.URL.Path, bytes.NewReader(item.Event)).WithContext(r.Context())
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	switch item.Type {
+	case "prompt":
+		InvokeWithStatus(bh.prompt.HandlePromptSubmit, http.StatusCreated)(rec, req)
+	case "response":
+		func() {
+			defer RecoverAndRespond(rec, req)
+			bh.response.HandleResponseSubmit(rec, req)
+		}()
+	default:
+		return BatchItemResult{Index: index, Success: false, Error: fmt.Sprintf("unknown batch item type %q", item.Type)}
+	}
+
+	var resp APIResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		return BatchItemResult{Index: index, Success: false, Error: "failed to decode handler response"}
+	}
+
+	result := BatchItemResult{Index: index, Success: rec.Code < 400, Data: resp.Data}
+	if !result.Success && resp.Error != nil {
+		result.Error = *resp.Error
+	}
+	return result
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBatchHandler_AllItemsSucceed(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	handler := NewBatchHandler(NewPromptHandler(db), NewResponseHandler(db))
+
+	body, _ := json.Marshal([]map[string]interface{}{
+		{
+			"type": "prompt",
+			"event": HookData{
+				SessionID: "batch-session",
+				Data:      map[string]interface{}{"prompt": "first prompt"},
+			},
+		},
+		{
+			"type": "response",
+			"event": HookData{
+				SessionID: "batch-session",
+				Data:      map[string]interface{}{"response": "first response"},
+			},
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/batch", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.HandleBatchSubmit(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var resp APIResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !resp.Success {
+		t.Error("Expected response.Success to be true")
+	}
+
+	results, ok := resp.Data.([]interface{})
+	if !ok || len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %+v", resp.Data)
+	}
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats["messages"] != 2 {
+		t.Errorf("Expected 2 messages created, got %v", stats["messages"])
+	}
+}
+
+func TestBatchHandler_PartialFailureReturnsMultiStatus(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	handler := NewBatchHandler(NewPromptHandler(db), NewResponseHandler(db))
+
+	body, _ := json.Marshal([]map[string]interface{}{
+		{
+			"type": "prompt",
+			"event": HookData{
+				SessionID: "batch-session",
+				Data:      map[string]interface{}{"prompt": "valid prompt"},
+			},
+		},
+		{
+			"type": "prompt",
+			// Missing session_id should fail this item without discarding the rest.
+			"event": HookData{
+				Data: map[string]interface{}{"prompt": "missing session id"},
+			},
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/batch", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.HandleBatchSubmit(w, req)
+
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("Expected status %d, got %d", http.StatusMultiStatus, w.Code)
+	}
+
+	var resp APIResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Success {
+		t.Error("Expected response.Success to be false when any item fails")
+	}
+
+	results, ok := resp.Data.([]interface{})
+	if !ok || len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %+v", resp.Data)
+	}
+
+	first := results[0].(map[string]interface{})
+	if first["success"] != true {
+		t.Errorf("Expected first item to succeed, got %+v", first)
+	}
+	second := results[1].(map[string]interface{})
+	if second["success"] != false {
+		t.Errorf("Expected second item to fail, got %+v", second)
+	}
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats["messages"] != 1 {
+		t.Errorf("Expected only the valid item to create a message, got %v", stats["messages"])
+	}
+}
+
+func TestBatchHandler_RejectsBatchExceedingMaxItems(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	handler := NewBatchHandler(NewPromptHandler(db), NewResponseHandler(db), WithBatchMaxItems(1))
+
+	body, _ := json.Marshal([]map[string]interface{}{
+		{"type": "prompt", "event": HookData{SessionID: "s", Data: map[string]interface{}{"prompt": "a"}}},
+		{"type": "prompt", "event": HookData{SessionID: "s", Data: map[string]interface{}{"prompt": "b"}}},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/batch", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.HandleBatchSubmit(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestBatchHandler_UnknownItemTypeFailsThatItemOnly(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	handler := NewBatchHandler(NewPromptHandler(db), NewResponseHandler(db))
+
+	body, _ := json.Marshal([]map[string]interface{}{
+		{"type": "carrier-pigeon", "event": HookData{SessionID: "s"}},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/batch", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.HandleBatchSubmit(w, req)
+
+	if w.Code != http.StatusMultiStatus {
+		t.Errorf("Expected status %d, got %d", http.StatusMultiStatus, w.Code)
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// DefaultHandlerDeadline is the deadline applied to a hook request when a
+// handler is not configured with an explicit one.
+const DefaultHandlerDeadline = 5 * time.Second
+
+// WithDeadline wraps fn so r's context carries a deadline of d. fn runs on
+// its own goroutine; if it has not returned by the time the deadline fires,
+// WithDeadline stops waiting and reports a 504 without unwinding fn, which
+// keeps running in the background with its result discarded. This mirrors
+// the read/write deadline pattern used by netstack-style adapters, where a
+// single time.AfterFunc closes a cancel channel that concurrent goroutines
+// select on.
+//
+// The deadline only protects the abandoned goroutine's caller; it stops
+// pinning the pooled connection (SetMaxOpenConns(1)) only once the DB call
+// fn makes actually observes ctx, via QueryContext/ExecContext/BeginTx(ctx,
+// ...). GetOrCreateConversationBySessionID and GetConversationBySessionID do
+// this; PromptHandler's db.CreateMessage call does not yet, because
+// CreateMessage itself has no definition in internal/database in this tree
+// to add ctx to.
+func WithDeadline(fn APIHandler, d time.Duration) APIHandler {
+	return func(r *http.Request) (*APIResponse, error) {
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+
+		type result struct {
+			resp *APIResponse
+			err  error
+		}
+
+		done := make(chan result, 1)
+		go func() {
+			resp, err := fn(r.WithContext(ctx))
+			done <- result{resp, err}
+		}()
+
+		select {
+		case res := <-done:
+			return res.resp, res.err
+		case <-ctx.Done():
+			return nil, &HTTPError{Msg: "deadline exceeded", Code: http.StatusGatewayTimeout}
+		}
+	}
+}
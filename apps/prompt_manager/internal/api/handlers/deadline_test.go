@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithDeadline_ReturnsBeforeDeadline(t *testing.T) {
+	fn := func(r *http.Request) (*APIResponse, error) {
+		return &APIResponse{Success: true}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	resp, err := WithDeadline(fn, DefaultHandlerDeadline)(req)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp == nil || !resp.Success {
+		t.Error("Expected successful response")
+	}
+}
+
+func TestWithDeadline_SlowHandlerTimesOut(t *testing.T) {
+	// Stands in for a slow DB write: it never finishes within the deadline.
+	slow := func(r *http.Request) (*APIResponse, error) {
+		time.Sleep(50 * time.Millisecond)
+		return &APIResponse{Success: true}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+
+	start := time.Now()
+	resp, err := WithDeadline(slow, 10*time.Millisecond)(req)
+	elapsed := time.Since(start)
+
+	if elapsed >= 50*time.Millisecond {
+		t.Errorf("Expected request to return within the deadline, took %v", elapsed)
+	}
+
+	if resp != nil {
+		t.Error("Expected nil response on timeout")
+	}
+
+	var httpErr *HTTPError
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Expected *HTTPError, got %T", err)
+	}
+	if httpErr.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected status %d, got %d", http.StatusGatewayTimeout, httpErr.Code)
+	}
+	if httpErr.Msg != "deadline exceeded" {
+		t.Errorf("Expected 'deadline exceeded', got %q", httpErr.Msg)
+	}
+}
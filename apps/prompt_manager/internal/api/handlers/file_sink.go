@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultFileSinkMaxBytes is the rotation threshold used when
+// WithFileSinkMaxBytes isn't supplied.
+const DefaultFileSinkMaxBytes = 64 << 20 // 64MiB
+
+// FileSink appends each event as a single NDJSON line to a file, rotating
+// to a new one once the active file exceeds maxBytes or the calendar date
+// changes, whichever comes first, so a long-running server doesn't grow one
+// unbounded file.
+type FileSink struct {
+	dir      string
+	prefix   string
+	maxBytes int64
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openDate string
+}
+
+// FileSinkOption configures a FileSink.
+type FileSinkOption func(*FileSink)
+
+// WithFileSinkMaxBytes overrides the rotation threshold. Defaults to
+// DefaultFileSinkMaxBytes.
+func WithFileSinkMaxBytes(n int64) FileSinkOption {
+	return func(fs *FileSink) {
+		fs.maxBytes = n
+	}
+}
+
+// NewFileSink creates a sink that appends NDJSON lines under dir, named
+// "<prefix>-<date>.ndjson", rotating to "<prefix>-<date>-<unixnano>.ndjson"
+// once the active file would exceed maxBytes.
+func NewFileSink(dir, prefix string, opts ...FileSinkOption) *FileSink {
+	fs := &FileSink{dir: dir, prefix: prefix, maxBytes: DefaultFileSinkMaxBytes}
+	for _, opt := range opts {
+		opt(fs)
+	}
+	return fs
+}
+
+func (fs *FileSink) Publish(_ context.Context, event SinkEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	line = append(line, '\n')
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.rotateIfNeeded(len(line)); err != nil {
+		return fmt.Errorf("failed to rotate event log: %w", err)
+	}
+
+	n, err := fs.file.Write(line)
+	fs.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
+
+func (fs *FileSink) rotateIfNeeded(nextWrite int) error {
+	today := time.Now().Format("2006-01-02")
+
+	if fs.file != nil && fs.openDate == today && fs.size+int64(nextWrite) <= fs.maxBytes {
+		return nil
+	}
+
+	if fs.file != nil {
+		fs.file.Close()
+		fs.file = nil
+	}
+
+	if err := os.MkdirAll(fs.dir, 0o755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(fs.dir, fmt.Sprintf("%s-%s.ndjson", fs.prefix, today))
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(nextWrite) > fs.maxBytes {
+		path = filepath.Join(fs.dir, fmt.Sprintf("%s-%s-%d.ndjson", fs.prefix, today, time.Now().UnixNano()))
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	fs.file = f
+	fs.openDate = today
+	if stat, err := f.Stat(); err == nil {
+		fs.size = stat.Size()
+	} else {
+		fs.size = 0
+	}
+	return nil
+}
+
+// Close closes the currently open file, if any. Safe to call more than once.
+func (fs *FileSink) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.file == nil {
+		return nil
+	}
+	err := fs.file.Close()
+	fs.file = nil
+	return err
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSink_Publish_AppendsNDJSONLines(t *testing.T) {
+	dir := t.TempDir()
+	sink := NewFileSink(dir, "events")
+	defer sink.Close()
+
+	for i := 0; i < 3; i++ {
+		event := SinkEvent{Type: "prompt", ConversationID: 1, MessageID: i}
+		if err := sink.Publish(context.Background(), event); err != nil {
+			t.Fatalf("Failed to publish event %d: %v", i, err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read sink dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected exactly 1 file before rotation, got %d", len(entries))
+	}
+
+	f, err := os.Open(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatalf("Failed to open event log: %v", err)
+	}
+	defer f.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event SinkEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Failed to unmarshal line %d: %v", lines, err)
+		}
+		if event.MessageID != lines {
+			t.Errorf("Expected line %d to carry message_id %d, got %d", lines, lines, event.MessageID)
+		}
+		lines++
+	}
+	if lines != 3 {
+		t.Fatalf("Expected 3 NDJSON lines, got %d", lines)
+	}
+}
+
+func TestFileSink_Publish_RotatesOnceMaxBytesExceeded(t *testing.T) {
+	dir := t.TempDir()
+	sink := NewFileSink(dir, "events", WithFileSinkMaxBytes(1))
+	defer sink.Close()
+
+	for i := 0; i < 3; i++ {
+		event := SinkEvent{Type: "prompt", ConversationID: 1, MessageID: i, Content: "padding to exceed one byte"}
+		if err := sink.Publish(context.Background(), event); err != nil {
+			t.Fatalf("Failed to publish event %d: %v", i, err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read sink dir: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected each publish past a 1-byte limit to rotate to its own file, got %d entries", len(entries))
+	}
+}
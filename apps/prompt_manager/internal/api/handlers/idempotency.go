@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/claude-code-template/prompt-manager/internal/database"
+)
+
+// IdempotencyKeyHeader is the request header a retried hook submission
+// carries to make /messages/prompt and /messages/response safe to resend
+// after a transient network failure.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// NewIdempotencyMiddleware returns middleware that makes a request carrying
+// an Idempotency-Key header safe to retry. The key is reserved before the
+// handler runs, so two concurrent retries presenting the same key can't both
+// pass the "has this key been seen yet" check and both run the handler's
+// side effects; the loser of that race gets 409 Conflict. Once the first
+// request's handler finishes, a retry presenting the same key and an
+// identical body replays the stored response byte-for-byte instead of
+// running the handler again, and a retry with a different body is rejected
+// with 409 Conflict so a reused key can never silently mask a different
+// request. A request with no Idempotency-Key header passes through
+// unchanged.
+func NewIdempotencyMiddleware(db *database.DB) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, errorResponseBody("failed to read request body"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := sha256.Sum256(body)
+			bodyHash := hex.EncodeToString(hash[:])
+
+			existing, err := db.GetIdempotencyRecord(key)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponseBody(err.Error()))
+				return
+			}
+			if existing != nil {
+				if existing.BodyHash != bodyHash {
+					writeJSON(w, http.StatusConflict, errorResponseBody("Idempotency-Key was already used with a different request body"))
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(existing.StatusCode)
+				w.Write(existing.ResponseBody)
+				return
+			}
+
+			// Best-effort session_id extraction for the stored record; an
+			// unparsable body still reaches the real handler and gets its own
+			// validation error.
+			var probe struct {
+				SessionID string `json:"session_id"`
+			}
+			json.Unmarshal(body, &probe)
+
+			won, err := db.ReserveIdempotencyKey(key, probe.SessionID, bodyHash)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponseBody(err.Error()))
+				return
+			}
+			if !won {
+				// Another request reserved (or has since completed) this key
+				// while we were reading the body; losing the race means we
+				// must not run the handler a second time.
+				writeJSON(w, http.StatusConflict, errorResponseBody("Idempotency-Key request already in progress"))
+				return
+			}
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			if rec.status >= 400 {
+				// The handler failed, so there's nothing worth replaying;
+				// release the reservation rather than leaving a dead key
+				// that would block every future retry.
+				if err := db.ReleaseIdempotencyKey(key); err != nil {
+					StdLogger{}.Log(map[string]interface{}{"op": "release_idempotency_key", "error": err.Error()})
+				}
+				return
+			}
+			if err := db.CompleteIdempotencyRecord(database.IdempotencyRecord{
+				Key:          key,
+				SessionID:    probe.SessionID,
+				BodyHash:     bodyHash,
+				StatusCode:   rec.status,
+				ResponseBody: rec.buf.Bytes(),
+			}); err != nil {
+				StdLogger{}.Log(map[string]interface{}{"op": "complete_idempotency_record", "error": err.Error()})
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyMiddleware_DuplicateKeySameBody_ReplaysOriginalResponse(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	handler := NewPromptHandler(db)
+	chain := NewIdempotencyMiddleware(db)(InvokeWithStatus(handler.HandlePromptSubmit, http.StatusCreated))
+
+	payload, _ := json.Marshal(HookData{
+		SessionID: "idempotent-session",
+		Data: map[string]interface{}{
+			"prompt": "Test prompt content",
+		},
+	})
+
+	first := httptest.NewRequest(http.MethodPost, "/messages/prompt", bytes.NewBuffer(payload))
+	first.Header.Set("Idempotency-Key", "key-1")
+	w1 := httptest.NewRecorder()
+	chain.ServeHTTP(w1, first)
+
+	if w1.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d on first request, got %d", http.StatusCreated, w1.Code)
+	}
+
+	second := httptest.NewRequest(http.MethodPost, "/messages/prompt", bytes.NewBuffer(payload))
+	second.Header.Set("Idempotency-Key", "key-1")
+	w2 := httptest.NewRecorder()
+	chain.ServeHTTP(w2, second)
+
+	if w2.Code != http.StatusCreated {
+		t.Errorf("Expected replayed status %d, got %d", http.StatusCreated, w2.Code)
+	}
+	if w2.Body.String() != w1.Body.String() {
+		t.Errorf("Expected replayed body to match the original, got %q vs %q", w2.Body.String(), w1.Body.String())
+	}
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats["messages"] != 1 {
+		t.Errorf("Expected exactly one message to have been created, got %v", stats["messages"])
+	}
+}
+
+func TestIdempotencyMiddleware_DuplicateKeyDifferentBody_ReturnsConflict(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	handler := NewPromptHandler(db)
+	chain := NewIdempotencyMiddleware(db)(InvokeWithStatus(handler.HandlePromptSubmit, http.StatusCreated))
+
+	payload, _ := json.Marshal(HookData{
+		SessionID: "idempotent-session",
+		Data:      map[string]interface{}{"prompt": "first prompt"},
+	})
+	first := httptest.NewRequest(http.MethodPost, "/messages/prompt", bytes.NewBuffer(payload))
+	first.Header.Set("Idempotency-Key", "key-2")
+	w1 := httptest.NewRecorder()
+	chain.ServeHTTP(w1, first)
+
+	if w1.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d on first request, got %d", http.StatusCreated, w1.Code)
+	}
+
+	otherPayload, _ := json.Marshal(HookData{
+		SessionID: "idempotent-session",
+		Data:      map[string]interface{}{"prompt": "a different prompt"},
+	})
+	second := httptest.NewRequest(http.MethodPost, "/messages/prompt", bytes.NewBuffer(otherPayload))
+	second.Header.Set("Idempotency-Key", "key-2")
+	w2 := httptest.NewRecorder()
+	chain.ServeHTTP(w2, second)
+
+	if w2.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w2.Code)
+	}
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats["messages"] != 1 {
+		t.Errorf("Expected the conflicting retry to create no message, got %v", stats["messages"])
+	}
+}
+
+func TestIdempotencyMiddleware_ConcurrentRetries_OnlyOneRunsTheHandler(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	handler := NewPromptHandler(db)
+
+	// A slow handler widens the window between the two requests' "has this
+	// key been reserved yet" checks, so without reserving the key up front
+	// both would pass it and both would call HandlePromptSubmit.
+	slow := func(r *http.Request) (*APIResponse, error) {
+		time.Sleep(50 * time.Millisecond)
+		return handler.HandlePromptSubmit(r)
+	}
+	chain := NewIdempotencyMiddleware(db)(InvokeWithStatus(slow, http.StatusCreated))
+
+	payload, _ := json.Marshal(HookData{
+		SessionID: "idempotent-concurrent-session",
+		Data:      map[string]interface{}{"prompt": "Test prompt content"},
+	})
+
+	codes := make([]int, 2)
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/messages/prompt", bytes.NewBuffer(payload))
+			req.Header.Set("Idempotency-Key", "key-concurrent")
+			w := httptest.NewRecorder()
+			chain.ServeHTTP(w, req)
+			codes[i] = w.Code
+		}(i)
+	}
+	wg.Wait()
+
+	var created, conflicts int
+	for _, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+			conflicts++
+		default:
+			t.Errorf("Unexpected status code %d", code)
+		}
+	}
+	if created != 1 || conflicts != 1 {
+		t.Fatalf("Expected exactly one request to succeed and the other to be rejected as a concurrent duplicate, got codes %v", codes)
+	}
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats["messages"] != 1 {
+		t.Errorf("Expected exactly one message despite two concurrent identical retries, got %v", stats["messages"])
+	}
+}
+
+func TestIdempotencyMiddleware_MissingKey_PreservesCurrentBehavior(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	handler := NewPromptHandler(db)
+	chain := NewIdempotencyMiddleware(db)(InvokeWithStatus(handler.HandlePromptSubmit, http.StatusCreated))
+
+	payload, _ := json.Marshal(HookData{
+		SessionID: "no-key-session",
+		Data:      map[string]interface{}{"prompt": "Test prompt content"},
+	})
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/messages/prompt", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+		chain.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+	}
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats["messages"] != 2 {
+		t.Errorf("Expected two separate messages without an Idempotency-Key, got %v", stats["messages"])
+	}
+}
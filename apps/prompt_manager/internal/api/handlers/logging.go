@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// RequestIDHeader is the response header the logging middleware echoes the
+// generated request ID on.
+const RequestIDHeader = "X-Request-ID"
+
+// Logger is implemented by anything that can record a structured log entry.
+// Tests provide their own implementation to capture output and assert on
+// fields instead of scraping stdout.
+type Logger interface {
+	Log(fields map[string]interface{})
+}
+
+// LoggerFunc adapts a plain function to Logger.
+type LoggerFunc func(fields map[string]interface{})
+
+// Log implements Logger.
+func (f LoggerFunc) Log(fields map[string]interface{}) { f(fields) }
+
+// StdLogger logs each entry as a single JSON line via the standard log
+// package. It is the default Logger used outside of tests.
+type StdLogger struct{}
+
+// Log implements Logger.
+func (StdLogger) Log(fields map[string]interface{}) {
+	if b, err := json.Marshal(fields); err == nil {
+		log.Println(string(b))
+	}
+}
+
+type requestIDCtxKey struct{}
+
+// RequestIDFromContext returns the request ID the logging middleware
+// generated for ctx's request, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDCtxKey{}).(string)
+	return id, ok
+}
+
+func newRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// WithRequestLogging wraps next with middleware that emits one structured
+// log line per request: request ID (also echoed as the X-Request-ID response
+// header), method, path, status, byte size, duration, and the session_id
+// extracted from the decoded hook response when available. 5xx responses
+// additionally carry the error message and a captured stack, so a single
+// hook failure can be traced end-to-end.
+func WithRequestLogging(next http.Handler, logger Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := newRequestID()
+		w.Header().Set(RequestIDHeader, requestID)
+		r = r.WithContext(context.WithValue(r.Context(), requestIDCtxKey{}, requestID))
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		fields := map[string]interface{}{
+			"request_id":  requestID,
+			"method":      r.Method,
+			"path":        r.URL.Path,
+			"status":      rec.status,
+			"bytes":       rec.bytes,
+			"duration_ms": time.Since(start).Milliseconds(),
+		}
+
+		var body APIResponse
+		if err := json.Unmarshal(rec.buf.Bytes(), &body); err == nil {
+			if data, ok := body.Data.(map[string]interface{}); ok {
+				if sessionID, ok := data["session_id"].(string); ok && sessionID != "" {
+					fields["session_id"] = sessionID
+				}
+			}
+			if rec.status >= 500 && body.Error != nil {
+				fields["error"] = *body.Error
+				fields["stack"] = string(debug.Stack())
+			}
+		}
+
+		logger.Log(fields)
+	})
+}
+
+// NewLoggingMiddleware returns a middleware function (the shape expected by
+// mux.Router.Use) that applies WithRequestLogging using logger.
+func NewLoggingMiddleware(logger Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return WithRequestLogging(next, logger)
+	}
+}
+
+// statusRecorder captures the status code and body written through it while
+// still forwarding every write to the underlying ResponseWriter.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+	buf    bytes.Buffer
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.buf.Write(b)
+	r.bytes += len(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type capturingLogger struct {
+	entries []map[string]interface{}
+}
+
+func (c *capturingLogger) Log(fields map[string]interface{}) {
+	c.entries = append(c.entries, fields)
+}
+
+func TestWithRequestLogging_RecordsRequestAndEchoesHeader(t *testing.T) {
+	logger := &capturingLogger{}
+	next := Invoke(func(r *http.Request) (*APIResponse, error) {
+		return &APIResponse{Success: true, Data: map[string]interface{}{"session_id": "sess-1"}}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/prompt", nil)
+	w := httptest.NewRecorder()
+
+	WithRequestLogging(next, logger).ServeHTTP(w, req)
+
+	if w.Header().Get(RequestIDHeader) == "" {
+		t.Error("Expected X-Request-ID header to be set")
+	}
+
+	if len(logger.entries) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(logger.entries))
+	}
+
+	entry := logger.entries[0]
+	if entry["method"] != http.MethodPost {
+		t.Errorf("Expected method %s, got %v", http.MethodPost, entry["method"])
+	}
+	if entry["status"] != http.StatusOK {
+		t.Errorf("Expected status %d, got %v", http.StatusOK, entry["status"])
+	}
+	if entry["session_id"] != "sess-1" {
+		t.Errorf("Expected session_id 'sess-1', got %v", entry["session_id"])
+	}
+	if entry["request_id"] != w.Header().Get(RequestIDHeader) {
+		t.Errorf("Expected logged request_id to match response header")
+	}
+}
+
+func TestWithRequestLogging_CapturesErrorAndStackOn5xx(t *testing.T) {
+	logger := &capturingLogger{}
+	next := Invoke(func(r *http.Request) (*APIResponse, error) {
+		return nil, &HTTPError{Msg: "boom", Code: http.StatusInternalServerError}
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/prompt", nil)
+	w := httptest.NewRecorder()
+
+	WithRequestLogging(next, logger).ServeHTTP(w, req)
+
+	entry := logger.entries[0]
+	if entry["error"] != "boom" {
+		t.Errorf("Expected error 'boom', got %v", entry["error"])
+	}
+	if entry["stack"] == nil || entry["stack"] == "" {
+		t.Error("Expected a captured stack trace for a 5xx response")
+	}
+}
@@ -1,72 +1,132 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/claude-code-template/prompt-manager/internal/database"
+	"github.com/claude-code-template/prompt-manager/internal/events"
 )
 
 // PromptHandler handles user prompt submissions
 type PromptHandler struct {
-	db *database.DB
+	db       *database.DB
+	deadline time.Duration
+	logger   Logger
+	bus      *events.Bus
+	sinks    []EventSink
 }
 
-// NewPromptHandler creates a new prompt handler
-func NewPromptHandler(db *database.DB) *PromptHandler {
-	return &PromptHandler{db: db}
+// PromptHandlerOption configures a PromptHandler.
+type PromptHandlerOption func(*PromptHandler)
+
+// WithPromptDeadline overrides the deadline applied to each prompt submit
+// request. Defaults to DefaultHandlerDeadline.
+func WithPromptDeadline(d time.Duration) PromptHandlerOption {
+	return func(ph *PromptHandler) {
+		ph.deadline = d
+	}
 }
 
+// WithPromptLogger overrides the logger used to record conversation lookup,
+// message creation, and sink publish failures. Defaults to StdLogger.
+func WithPromptLogger(l Logger) PromptHandlerOption {
+	return func(ph *PromptHandler) {
+		ph.logger = l
+	}
+}
 
-// HandlePromptSubmit processes user prompt submissions
-func (ph *PromptHandler) HandlePromptSubmit(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+// WithPromptEventBus overrides the events.Bus that HandlePromptSubmit
+// publishes "conversation.created" events to for a brand new session, and
+// registers a BusSink so "prompt" events reach the same bus through the
+// EventSink fan-out, so a web UI subscribed to Server.StreamHandler sees
+// hook-submitted prompts without polling. Defaults to nil, which disables
+// publishing.
+func WithPromptEventBus(bus *events.Bus) PromptHandlerOption {
+	return func(ph *PromptHandler) {
+		ph.bus = bus
+		ph.sinks = append(ph.sinks, &BusSink{Bus: bus})
+	}
+}
 
+// WithPromptEventSink registers an additional EventSink that every "prompt"
+// event is fanned out to, alongside the bus wired up by WithPromptEventBus
+// (if any). Use this to plug in a WebhookSink, a FileSink, or a test fake.
+func WithPromptEventSink(sink EventSink) PromptHandlerOption {
+	return func(ph *PromptHandler) {
+		ph.sinks = append(ph.sinks, sink)
+	}
+}
+
+// NewPromptHandler creates a new prompt handler
+func NewPromptHandler(db *database.DB, opts ...PromptHandlerOption) *PromptHandler {
+	ph := &PromptHandler{db: db, deadline: DefaultHandlerDeadline, logger: StdLogger{}}
+	for _, opt := range opts {
+		opt(ph)
+	}
+	return ph
+}
+
+// HandlePromptSubmit processes user prompt submissions. It is registered
+// through InvokeWithStatus(ph.HandlePromptSubmit, http.StatusCreated), which
+// takes care of decoding errors, panic recovery, and writing the response.
+// The request is bounded by ph.deadline so a slow DB write cannot pin a hook
+// connection indefinitely.
+func (ph *PromptHandler) HandlePromptSubmit(r *http.Request) (*APIResponse, error) {
+	return WithDeadline(ph.handlePromptSubmit, ph.deadline)(r)
+}
+
+func (ph *PromptHandler) handlePromptSubmit(r *http.Request) (*APIResponse, error) {
 	if r.Method != http.MethodPost {
-		ph.errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+		return nil, &HTTPError{Msg: "Method not allowed", Code: http.StatusMethodNotAllowed}
 	}
 
 	var hookData HookData
 	if err := json.NewDecoder(r.Body).Decode(&hookData); err != nil {
-		ph.errorResponse(w, "Invalid JSON request body", http.StatusBadRequest)
-		return
+		return nil, &HTTPError{Msg: "Invalid JSON request body", Code: http.StatusBadRequest}
 	}
 
 	if hookData.SessionID == "" {
-		ph.errorResponse(w, "session_id is required", http.StatusBadRequest)
-		return
+		return nil, &HTTPError{Msg: "session_id is required", Code: http.StatusBadRequest}
 	}
 
 	// Extract prompt content from hook data
 	promptData, ok := hookData.Data["prompt"]
 	if !ok {
-		ph.errorResponse(w, "no prompt data in request", http.StatusBadRequest)
-		return
+		return nil, &HTTPError{Msg: "no prompt data in request", Code: http.StatusBadRequest}
 	}
 
 	prompt, ok := promptData.(string)
 	if !ok {
-		ph.errorResponse(w, "prompt data must be a string", http.StatusBadRequest)
-		return
+		return nil, &HTTPError{Msg: "prompt data must be a string", Code: http.StatusBadRequest}
 	}
 
 	// Get or create conversation
-	conversationID, err := ph.getOrCreateConversation(hookData.SessionID, hookData.Data)
+	conversationID, err := ph.getOrCreateConversation(r, hookData.SessionID, hookData.Data)
 	if err != nil {
-		ph.errorResponse(w, fmt.Sprintf("Failed to get or create conversation: %v", err), http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("failed to get or create conversation: %w", err)
 	}
 
 	// Create message record
 	message, err := ph.db.CreateMessage(conversationID, "prompt", prompt, nil, nil)
 	if err != nil {
-		ph.errorResponse(w, fmt.Sprintf("Failed to create message: %v", err), http.StatusInternalServerError)
-		return
+		ph.logError(r, "create_message", err)
+		return nil, fmt.Errorf("failed to create message: %w", err)
 	}
 
-	response := APIResponse{
+	ph.publishToSinks(r, SinkEvent{
+		Type:           "prompt",
+		ConversationID: conversationID,
+		SessionID:      hookData.SessionID,
+		MessageID:      message.ID,
+		Content:        prompt,
+		Timestamp:      time.Now(),
+	})
+
+	return &APIResponse{
 		Success: true,
 		Data: map[string]interface{}{
 			"message_id":      message.ID,
@@ -75,37 +135,56 @@ func (ph *PromptHandler) HandlePromptSubmit(w http.ResponseWriter, r *http.Reque
 			"type":            "prompt",
 			"timestamp":       message.Timestamp,
 		},
-	}
-
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	}, nil
 }
 
-// getOrCreateConversation gets existing conversation or creates a new one
-func (ph *PromptHandler) getOrCreateConversation(sessionID string, data map[string]interface{}) (int, error) {
-	// Try to find existing conversation for this session
-	conversations, err := ph.db.ListConversations(10, 0)
+// getOrCreateConversation gets the existing conversation for sessionID or
+// creates it, via the indexed session_id lookup so it scales with history
+// and two concurrent hooks for the same session cannot create duplicates.
+func (ph *PromptHandler) getOrCreateConversation(r *http.Request, sessionID string, data map[string]interface{}) (int, error) {
+	workingDir := extractStringFromData(data, "cwd")
+	transcriptPath := extractStringFromData(data, "transcript_path")
+
+	conv, created, err := ph.db.GetOrCreateConversationBySessionID(r.Context(), sessionID, workingDir, transcriptPath)
 	if err != nil {
-		return 0, fmt.Errorf("failed to list conversations: %w", err)
+		ph.logError(r, "get_or_create_conversation", err)
+		return 0, fmt.Errorf("failed to get or create conversation: %w", err)
 	}
 
-	// Check if any conversation matches this session
-	for _, conv := range conversations {
-		if conv.SessionID == sessionID {
-			return conv.ID, nil
-		}
+	if created && ph.bus != nil {
+		ph.bus.Publish(events.GlobalTopic, "conversation.created", conv)
 	}
 
-	// Create new conversation
-	workingDir := extractStringFromData(data, "cwd")
-	transcriptPath := extractStringFromData(data, "transcript_path")
+	return conv.ID, nil
+}
 
-	conv, err := ph.db.CreateConversation(sessionID, nil, workingDir, transcriptPath)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create conversation: %w", err)
+// publishToSinks fans event out to every configured sink concurrently.
+// SQLite persistence has already succeeded by the time this runs and
+// remains the source of truth for the HTTP response; a sink failure here
+// is only logged, so a slow or unreachable webhook can't hold up a hook
+// client waiting on its reply.
+func (ph *PromptHandler) publishToSinks(r *http.Request, event SinkEvent) {
+	for _, sink := range ph.sinks {
+		go func(sink EventSink) {
+			ctx, cancel := context.WithTimeout(context.Background(), sinkPublishTimeout)
+			defer cancel()
+			if err := sink.Publish(ctx, event); err != nil {
+				ph.logError(r, "sink_publish", err)
+			}
+		}(sink)
 	}
+}
 
-	return conv.ID, nil
+// logError records a conversation lookup/creation, message creation, or
+// sink publish failure with the request ID generated by the logging
+// middleware, so a single hook failure can be
+// traced end-to-end.
+func (ph *PromptHandler) logError(r *http.Request, op string, err error) {
+	fields := map[string]interface{}{"op": op, "error": err.Error()}
+	if requestID, ok := RequestIDFromContext(r.Context()); ok {
+		fields["request_id"] = requestID
+	}
+	ph.logger.Log(fields)
 }
 
 // extractStringFromData safely extracts a string value from map data
@@ -117,13 +196,3 @@ func extractStringFromData(data map[string]interface{}, key string) *string {
 	}
 	return nil
 }
-
-// errorResponse sends an error response
-func (ph *PromptHandler) errorResponse(w http.ResponseWriter, message string, statusCode int) {
-	w.WriteHeader(statusCode)
-	response := APIResponse{
-		Success: false,
-		Error:   &message,
-	}
-	json.NewEncoder(w).Encode(response)
-}
\ No newline at end of file
@@ -2,16 +2,48 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/claude-code-template/prompt-manager/internal/database"
 )
 
+// fakeSink is an EventSink test double that records every event it
+// receives, optionally after an injected delay, so tests can assert both
+// successful delivery and that a slow sink doesn't block the caller.
+type fakeSink struct {
+	delay time.Duration
+
+	mu       sync.Mutex
+	events   []SinkEvent
+	received chan struct{}
+}
+
+func (fs *fakeSink) Publish(ctx context.Context, event SinkEvent) error {
+	if fs.delay > 0 {
+		select {
+		case <-time.After(fs.delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	fs.mu.Lock()
+	fs.events = append(fs.events, event)
+	fs.mu.Unlock()
+
+	if fs.received != nil {
+		fs.received <- struct{}{}
+	}
+	return nil
+}
+
 func setupTestDB(t *testing.T) *database.DB {
 	// Create temporary database file
 	tmpDir := t.TempDir()
@@ -76,12 +108,13 @@ func TestPromptHandler_HandlePromptSubmit_Success(t *testing.T) {
 	// Create request
 	req := httptest.NewRequest(http.MethodPost, "/messages/prompt", bytes.NewBuffer(payload))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-hook-token")
 	
 	// Create response recorder
 	w := httptest.NewRecorder()
 	
 	// Execute request
-	handler.HandlePromptSubmit(w, req)
+	InvokeWithStatus(handler.HandlePromptSubmit, http.StatusCreated)(w, req)
 	
 	// Check response
 	if w.Code != http.StatusCreated {
@@ -127,7 +160,7 @@ func TestPromptHandler_HandlePromptSubmit_MethodNotAllowed(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/messages/prompt", nil)
 	w := httptest.NewRecorder()
 	
-	handler.HandlePromptSubmit(w, req)
+	InvokeWithStatus(handler.HandlePromptSubmit, http.StatusCreated)(w, req)
 	
 	if w.Code != http.StatusMethodNotAllowed {
 		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
@@ -156,9 +189,10 @@ func TestPromptHandler_HandlePromptSubmit_InvalidJSON(t *testing.T) {
 	// Create request with invalid JSON
 	req := httptest.NewRequest(http.MethodPost, "/messages/prompt", bytes.NewBufferString("invalid json"))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-hook-token")
 	
 	w := httptest.NewRecorder()
-	handler.HandlePromptSubmit(w, req)
+	InvokeWithStatus(handler.HandlePromptSubmit, http.StatusCreated)(w, req)
 	
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -199,9 +233,10 @@ func TestPromptHandler_HandlePromptSubmit_MissingSessionID(t *testing.T) {
 	
 	req := httptest.NewRequest(http.MethodPost, "/messages/prompt", bytes.NewBuffer(payload))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-hook-token")
 	
 	w := httptest.NewRecorder()
-	handler.HandlePromptSubmit(w, req)
+	InvokeWithStatus(handler.HandlePromptSubmit, http.StatusCreated)(w, req)
 	
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -241,9 +276,10 @@ func TestPromptHandler_HandlePromptSubmit_MissingPromptData(t *testing.T) {
 	
 	req := httptest.NewRequest(http.MethodPost, "/messages/prompt", bytes.NewBuffer(payload))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-hook-token")
 	
 	w := httptest.NewRecorder()
-	handler.HandlePromptSubmit(w, req)
+	InvokeWithStatus(handler.HandlePromptSubmit, http.StatusCreated)(w, req)
 	
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -282,9 +318,10 @@ func TestPromptHandler_HandlePromptSubmit_InvalidPromptDataType(t *testing.T) {
 	
 	req := httptest.NewRequest(http.MethodPost, "/messages/prompt", bytes.NewBuffer(payload))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-hook-token")
 	
 	w := httptest.NewRecorder()
-	handler.HandlePromptSubmit(w, req)
+	InvokeWithStatus(handler.HandlePromptSubmit, http.StatusCreated)(w, req)
 	
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -370,4 +407,91 @@ func TestPromptHandler_CreateConversationAndMessage(t *testing.T) {
 	if conversationID1 != conversationID2 {
 		t.Errorf("Expected same conversation ID for same session, got %v and %v", conversationID1, conversationID2)
 	}
+}
+
+func TestPromptHandler_HandlePromptSubmit_DeliversEventToConfiguredSink(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	sink := &fakeSink{received: make(chan struct{}, 1)}
+	handler := NewPromptHandler(db, WithPromptEventSink(sink))
+
+	hookData := HookData{
+		Event:     "UserPromptSubmit",
+		Timestamp: time.Now().Format(time.RFC3339),
+		SessionID: "sink-session",
+		Filename:  "activity-monitor",
+		Data: map[string]interface{}{
+			"prompt": "Test prompt content",
+		},
+	}
+	payload, err := json.Marshal(hookData)
+	if err != nil {
+		t.Fatalf("Failed to marshal test data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/prompt", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	InvokeWithStatus(handler.HandlePromptSubmit, http.StatusCreated)(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	select {
+	case <-sink.received:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the configured sink to receive the prompt event")
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.events) != 1 {
+		t.Fatalf("Expected exactly 1 event delivered to the sink, got %d", len(sink.events))
+	}
+	if sink.events[0].Content != "Test prompt content" {
+		t.Errorf("Expected sink event to carry the prompt content, got %q", sink.events[0].Content)
+	}
+	if sink.events[0].Type != "prompt" {
+		t.Errorf("Expected sink event type %q, got %q", "prompt", sink.events[0].Type)
+	}
+}
+
+func TestPromptHandler_HandlePromptSubmit_SlowSinkDoesNotDelayResponse(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	sink := &fakeSink{delay: 2 * time.Second}
+	handler := NewPromptHandler(db, WithPromptEventSink(sink))
+
+	hookData := HookData{
+		Event:     "UserPromptSubmit",
+		Timestamp: time.Now().Format(time.RFC3339),
+		SessionID: "slow-sink-session",
+		Filename:  "activity-monitor",
+		Data: map[string]interface{}{
+			"prompt": "Test prompt content",
+		},
+	}
+	payload, err := json.Marshal(hookData)
+	if err != nil {
+		t.Fatalf("Failed to marshal test data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/prompt", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	InvokeWithStatus(handler.HandlePromptSubmit, http.StatusCreated)(w, req)
+	elapsed := time.Since(start)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the response to return well before the slow sink's 2s delay, took %s", elapsed)
+	}
 }
\ No newline at end of file
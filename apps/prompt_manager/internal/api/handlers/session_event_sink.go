@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SessionEvent is the payload a SessionEventSink delivers for a
+// SessionStart/SessionEnd lifecycle event. prompt-manager doesn't tokenize
+// message content anywhere, so CharacterCount stands in for the "token
+// counts" an external dashboard might otherwise expect.
+type SessionEvent struct {
+	Event          string        `json:"event"`
+	ConversationID int           `json:"conversation_id"`
+	SessionID      string        `json:"session_id"`
+	PromptCount    int           `json:"prompt_count"`
+	CharacterCount int           `json:"character_count"`
+	Duration       time.Duration `json:"duration_ns"`
+	Timestamp      time.Time     `json:"timestamp"`
+}
+
+// SessionEventSink receives SessionStart/SessionEnd lifecycle notifications.
+// Notify must not block the caller: it runs on the hook request path, so an
+// implementation that wants to retry or deliver over the network has to do
+// so on its own goroutine.
+type SessionEventSink interface {
+	Notify(event SessionEvent)
+}
+
+// DefaultSessionWebhookQueueSize bounds how many SessionEvents
+// WebhookSessionSink holds in memory while a delivery is in flight. It has
+// no disk-backed overflow, unlike WebhookSink: a lifecycle notification that
+// can't be queued is dropped rather than replayed later.
+const DefaultSessionWebhookQueueSize = 256
+
+// DefaultSessionWebhookMaxRetries is how many additional delivery attempts
+// WebhookSessionSink makes, with exponential backoff, before giving up on an
+// event.
+const DefaultSessionWebhookMaxRetries = 3
+
+// DefaultSessionWebhookBaseDelay is the backoff applied after the first
+// failed attempt; it doubles on each subsequent retry.
+const DefaultSessionWebhookBaseDelay = 200 * time.Millisecond
+
+// WebhookSessionSink POSTs each SessionEvent as JSON to a configured URL.
+// Notify enqueues onto a bounded in-memory channel and returns immediately;
+// a single background goroutine drains it and delivers with retry, so a
+// slow or unreachable endpoint can never block the hook handler that called
+// Notify. Unlike WebhookSink, a queue-full event is dropped (and logged)
+// rather than written to disk: lifecycle notifications are a best-effort
+// feed for dashboards/alerting, not the record of truth for a session.
+type WebhookSessionSink struct {
+	url        string
+	client     *http.Client
+	maxRetries int
+	baseDelay  time.Duration
+	logger     Logger
+
+	queue chan SessionEvent
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// WebhookSessionSinkOption configures a WebhookSessionSink.
+type WebhookSessionSinkOption func(*WebhookSessionSink)
+
+// WithSessionWebhookClient overrides the *http.Client used to deliver
+// events. Defaults to http.DefaultClient.
+func WithSessionWebhookClient(c *http.Client) WebhookSessionSinkOption {
+	return func(ws *WebhookSessionSink) {
+		ws.client = c
+	}
+}
+
+// WithSessionWebhookMaxRetries overrides how many retries are attempted
+// before an event is dropped. Defaults to DefaultSessionWebhookMaxRetries.
+func WithSessionWebhookMaxRetries(n int) WebhookSessionSinkOption {
+	return func(ws *WebhookSessionSink) {
+		ws.maxRetries = n
+	}
+}
+
+// WithSessionWebhookBaseDelay overrides the backoff applied after the first
+// failed attempt. Defaults to DefaultSessionWebhookBaseDelay.
+func WithSessionWebhookBaseDelay(d time.Duration) WebhookSessionSinkOption {
+	return func(ws *WebhookSessionSink) {
+		ws.baseDelay = d
+	}
+}
+
+// WithSessionWebhookQueueSize overrides how many events Notify can buffer
+// while a delivery is in flight. Defaults to DefaultSessionWebhookQueueSize.
+func WithSessionWebhookQueueSize(n int) WebhookSessionSinkOption {
+	return func(ws *WebhookSessionSink) {
+		ws.queue = make(chan SessionEvent, n)
+	}
+}
+
+// WithSessionWebhookLogger overrides the logger used to record dropped and
+// failed deliveries. Defaults to StdLogger.
+func WithSessionWebhookLogger(l Logger) WebhookSessionSinkOption {
+	return func(ws *WebhookSessionSink) {
+		ws.logger = l
+	}
+}
+
+// NewWebhookSessionSink creates a sink that POSTs session lifecycle events
+// to url and starts its background delivery goroutine. Callers should call
+// Close when done to stop that goroutine.
+func NewWebhookSessionSink(url string, opts ...WebhookSessionSinkOption) *WebhookSessionSink {
+	ws := &WebhookSessionSink{
+		url:        url,
+		client:     http.DefaultClient,
+		maxRetries: DefaultSessionWebhookMaxRetries,
+		baseDelay:  DefaultSessionWebhookBaseDelay,
+		logger:     StdLogger{},
+		queue:      make(chan SessionEvent, DefaultSessionWebhookQueueSize),
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(ws)
+	}
+
+	ws.wg.Add(1)
+	go ws.run()
+
+	return ws
+}
+
+// Notify enqueues event for delivery, following the same non-blocking,
+// drop-when-full pattern as events.Bus: a full queue means the webhook
+// endpoint is falling behind, and the hook request that triggered this
+// event must not be made to wait for it to catch up.
+func (ws *WebhookSessionSink) Notify(event SessionEvent) {
+	select {
+	case ws.queue <- event:
+	default:
+		ws.logger.Log(map[string]interface{}{
+			"op":    "session_webhook_notify",
+			"event": event.Event,
+			"error": "queue full, dropping session event",
+		})
+	}
+}
+
+// Close stops the background delivery goroutine and returns once it has
+// exited. Any event still queued at that point is dropped.
+func (ws *WebhookSessionSink) Close() error {
+	close(ws.done)
+	ws.wg.Wait()
+	return nil
+}
+
+func (ws *WebhookSessionSink) run() {
+	defer ws.wg.Done()
+
+	for {
+		select {
+		case event := <-ws.queue:
+			ws.deliverWithRetry(event)
+		case <-ws.done:
+			return
+		}
+	}
+}
+
+func (ws *WebhookSessionSink) deliverWithRetry(event SessionEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		ws.logger.Log(map[string]interface{}{"op": "session_webhook_marshal", "error": err.Error()})
+		return
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= ws.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(ws.baseDelay * time.Duration(1<<(attempt-1))):
+			case <-ws.done:
+				return
+			}
+		}
+
+		if err := ws.deliver(body); err != nil {
+			lastErr = err
+			continue
+		}
+		return
+	}
+
+	ws.logger.Log(map[string]interface{}{
+		"op":    "session_webhook_deliver",
+		"event": event.Event,
+		"error": fmt.Sprintf("delivery failed after %d attempts: %v", ws.maxRetries+1, lastErr),
+	})
+}
+
+func (ws *WebhookSessionSink) deliver(body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sinkPublishTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ws.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
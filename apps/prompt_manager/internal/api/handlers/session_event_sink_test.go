@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookSessionSink_Notify_DeliversOnFirstAttempt(t *testing.T) {
+	received := make(chan SessionEvent, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event SessionEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		received <- event
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSessionSink(srv.URL, WithSessionWebhookBaseDelay(time.Millisecond))
+	defer sink.Close()
+
+	sink.Notify(SessionEvent{Event: "session_start", ConversationID: 1, SessionID: "s1"})
+
+	select {
+	case event := <-received:
+		if event.Event != "session_start" || event.ConversationID != 1 {
+			t.Errorf("Unexpected delivered event: %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the webhook to have been called")
+	}
+}
+
+func TestWebhookSessionSink_Notify_RetriesThenSucceeds(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSessionSink(srv.URL, WithSessionWebhookBaseDelay(time.Millisecond))
+	defer sink.Close()
+
+	sink.Notify(SessionEvent{Event: "session_end", ConversationID: 1, SessionID: "s1"})
+
+	deadline := time.After(time.Second)
+	for atomic.LoadInt32(&attempts) != 3 {
+		select {
+		case <-deadline:
+			t.Fatalf("Expected exactly 3 attempts, got %d", atomic.LoadInt32(&attempts))
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestWebhookSessionSink_Notify_DoesNotBlockWhenQueueIsFull(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	var logged int32
+	sink := NewWebhookSessionSink(srv.URL,
+		WithSessionWebhookQueueSize(1),
+		WithSessionWebhookLogger(LoggerFunc(func(map[string]interface{}) { atomic.AddInt32(&logged, 1) })),
+	)
+	defer func() {
+		close(block)
+		sink.Close()
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		// One event is picked up immediately by the background goroutine and
+		// blocks on the handler above; the next fills the size-1 queue; a
+		// third must be dropped rather than blocking this call.
+		sink.Notify(SessionEvent{Event: "session_start"})
+		sink.Notify(SessionEvent{Event: "session_start"})
+		sink.Notify(SessionEvent{Event: "session_start"})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Notify to never block even with a stalled delivery and a full queue")
+	}
+}
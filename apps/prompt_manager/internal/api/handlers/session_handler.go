@@ -4,101 +4,167 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/claude-code-template/prompt-manager/internal/database"
 )
 
 // SessionHandler handles session events (start/stop)
 type SessionHandler struct {
-	db *database.DB
+	db       *database.DB
+	deadline time.Duration
+	logger   Logger
+	sinks    []SessionEventSink
+}
+
+// SessionHandlerOption configures a SessionHandler.
+type SessionHandlerOption func(*SessionHandler)
+
+// WithSessionDeadline overrides the deadline applied to each session event
+// request. Defaults to DefaultHandlerDeadline.
+func WithSessionDeadline(d time.Duration) SessionHandlerOption {
+	return func(sh *SessionHandler) {
+		sh.deadline = d
+	}
+}
+
+// WithSessionLogger overrides the logger used to record conversation lookup
+// failures. Defaults to StdLogger.
+func WithSessionLogger(l Logger) SessionHandlerOption {
+	return func(sh *SessionHandler) {
+		sh.logger = l
+	}
+}
+
+// WithSessionEventSink registers a sink to be notified of every
+// SessionStart/SessionEnd event, in addition to the API response. Can be
+// passed more than once to fan out to several sinks.
+func WithSessionEventSink(sink SessionEventSink) SessionHandlerOption {
+	return func(sh *SessionHandler) {
+		sh.sinks = append(sh.sinks, sink)
+	}
 }
 
 // NewSessionHandler creates a new session handler
-func NewSessionHandler(db *database.DB) *SessionHandler {
-	return &SessionHandler{db: db}
+func NewSessionHandler(db *database.DB, opts ...SessionHandlerOption) *SessionHandler {
+	sh := &SessionHandler{db: db, deadline: DefaultHandlerDeadline, logger: StdLogger{}}
+	for _, opt := range opts {
+		opt(sh)
+	}
+	return sh
 }
 
-// HandleSessionEvent processes session start/stop events
-func (sh *SessionHandler) HandleSessionEvent(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+// HandleSessionEvent processes session start/stop events. It is registered
+// through Invoke, which takes care of decoding errors, panic recovery, and
+// writing the response. The request is bounded by sh.deadline so a slow DB
+// write cannot pin a hook connection indefinitely.
+func (sh *SessionHandler) HandleSessionEvent(r *http.Request) (*APIResponse, error) {
+	return WithDeadline(sh.handleSessionEvent, sh.deadline)(r)
+}
 
+func (sh *SessionHandler) handleSessionEvent(r *http.Request) (*APIResponse, error) {
 	if r.Method != http.MethodPost {
-		ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+		return nil, &HTTPError{Msg: "Method not allowed", Code: http.StatusMethodNotAllowed}
 	}
 
 	var hookData HookData
 	if err := json.NewDecoder(r.Body).Decode(&hookData); err != nil {
-		ErrorResponse(w, "Invalid JSON request body", http.StatusBadRequest)
-		return
+		return nil, &HTTPError{Msg: "Invalid JSON request body", Code: http.StatusBadRequest}
 	}
 
 	if hookData.SessionID == "" {
-		ErrorResponse(w, "session_id is required", http.StatusBadRequest)
-		return
+		return nil, &HTTPError{Msg: "session_id is required", Code: http.StatusBadRequest}
 	}
 
 	switch hookData.Event {
 	case "SessionStart":
-		sh.handleSessionStart(w, &hookData)
-		return
+		return sh.handleSessionStart(r, &hookData)
 	case "SessionEnd", "Stop":
-		sh.handleSessionEnd(w, &hookData)
-		return
+		return sh.handleSessionEnd(r, &hookData)
 	default:
-		ErrorResponse(w, fmt.Sprintf("Unknown session event: %s", hookData.Event), http.StatusBadRequest)
-		return
+		return nil, &HTTPError{Msg: fmt.Sprintf("Unknown session event: %s", hookData.Event), Code: http.StatusBadRequest}
 	}
 }
 
 // handleSessionStart processes session start events
-func (sh *SessionHandler) handleSessionStart(w http.ResponseWriter, hookData *HookData) {
-	// Get or create conversation
-	conversationID, err := GetOrCreateConversation(sh.db, hookData.SessionID, hookData.Data)
+func (sh *SessionHandler) handleSessionStart(r *http.Request, hookData *HookData) (*APIResponse, error) {
+	// Get or create the conversation via the indexed session_id lookup, so
+	// two concurrent SessionStart hooks for the same session cannot create
+	// duplicate conversation rows.
+	workingDir := extractStringFromData(hookData.Data, "cwd")
+	transcriptPath := extractStringFromData(hookData.Data, "transcript_path")
+
+	conv, _, err := sh.db.GetOrCreateConversationBySessionID(r.Context(), hookData.SessionID, workingDir, transcriptPath)
 	if err != nil {
-		ErrorResponse(w, fmt.Sprintf("Failed to get or create conversation: %v", err), http.StatusInternalServerError)
-		return
+		sh.logError(r, "get_or_create_conversation", err)
+		return nil, fmt.Errorf("failed to get or create conversation: %w", err)
 	}
 
-	response := APIResponse{
+	sh.notify(SessionEvent{
+		Event:          "session_start",
+		ConversationID: conv.ID,
+		SessionID:      hookData.SessionID,
+		PromptCount:    conv.PromptCount,
+		CharacterCount: conv.TotalCharacters,
+		Timestamp:      time.Now(),
+	})
+
+	return &APIResponse{
 		Success: true,
 		Data: map[string]interface{}{
 			"event":           "session_start",
-			"conversation_id": conversationID,
+			"conversation_id": conv.ID,
 			"session_id":      hookData.SessionID,
 		},
-	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	}, nil
 }
 
 // handleSessionEnd processes session end/stop events
-func (sh *SessionHandler) handleSessionEnd(w http.ResponseWriter, hookData *HookData) {
-	// Try to find existing conversation for this session
-	conversations, err := sh.db.ListConversations(10, 0)
+func (sh *SessionHandler) handleSessionEnd(r *http.Request, hookData *HookData) (*APIResponse, error) {
+	conv, found, err := sh.db.GetConversationBySessionID(r.Context(), hookData.SessionID)
 	if err != nil {
-		ErrorResponse(w, fmt.Sprintf("Failed to list conversations: %v", err), http.StatusInternalServerError)
-		return
+		sh.logError(r, "get_conversation_by_session_id", err)
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
 	}
 
 	var conversationID *int
-	for _, conv := range conversations {
-		if conv.SessionID == hookData.SessionID {
-			conversationID = &conv.ID
-			break
-		}
+	if found {
+		conversationID = &conv.ID
+		sh.notify(SessionEvent{
+			Event:          "session_end",
+			ConversationID: conv.ID,
+			SessionID:      hookData.SessionID,
+			PromptCount:    conv.PromptCount,
+			CharacterCount: conv.TotalCharacters,
+			Duration:       time.Since(conv.CreatedAt),
+			Timestamp:      time.Now(),
+		})
 	}
 
-	response := APIResponse{
+	return &APIResponse{
 		Success: true,
 		Data: map[string]interface{}{
 			"event":           "session_end",
 			"conversation_id": conversationID,
 			"session_id":      hookData.SessionID,
 		},
+	}, nil
+}
+
+// notify fans event out to every registered sink.
+func (sh *SessionHandler) notify(event SessionEvent) {
+	for _, sink := range sh.sinks {
+		sink.Notify(event)
 	}
+}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+// logError records a conversation lookup failure with the request ID
+// generated by the logging middleware, so a single hook failure can be
+// traced end-to-end.
+func (sh *SessionHandler) logError(r *http.Request, op string, err error) {
+	fields := map[string]interface{}{"op": op, "error": err.Error()}
+	if requestID, ok := RequestIDFromContext(r.Context()); ok {
+		fields["request_id"] = requestID
+	}
+	sh.logger.Log(fields)
 }
@@ -50,12 +50,13 @@ func TestSessionHandler_HandleSessionEvent_SessionStart(t *testing.T) {
 	// Create request
 	req := httptest.NewRequest(http.MethodPost, "/messages/session", bytes.NewBuffer(payload))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-hook-token")
 	
 	// Create response recorder
 	w := httptest.NewRecorder()
 	
 	// Execute request
-	handler.HandleSessionEvent(w, req)
+	Invoke(handler.HandleSessionEvent)(w, req)
 	
 	// Check response
 	if w.Code != http.StatusOK {
@@ -118,12 +119,13 @@ func TestSessionHandler_HandleSessionEvent_SessionEnd(t *testing.T) {
 	// Create request
 	req := httptest.NewRequest(http.MethodPost, "/messages/session", bytes.NewBuffer(payload))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-hook-token")
 	
 	// Create response recorder
 	w := httptest.NewRecorder()
 	
 	// Execute request
-	handler.HandleSessionEvent(w, req)
+	Invoke(handler.HandleSessionEvent)(w, req)
 	
 	// Check response
 	if w.Code != http.StatusOK {
@@ -155,7 +157,7 @@ func TestSessionHandler_HandleSessionEvent_MethodNotAllowed(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/messages/session", nil)
 	w := httptest.NewRecorder()
 	
-	handler.HandleSessionEvent(w, req)
+	Invoke(handler.HandleSessionEvent)(w, req)
 	
 	if w.Code != http.StatusMethodNotAllowed {
 		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
@@ -182,9 +184,10 @@ func TestSessionHandler_HandleSessionEvent_InvalidJSON(t *testing.T) {
 	// Create request with invalid JSON
 	req := httptest.NewRequest(http.MethodPost, "/messages/session", bytes.NewBufferString("invalid json"))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-hook-token")
 	
 	w := httptest.NewRecorder()
-	handler.HandleSessionEvent(w, req)
+	Invoke(handler.HandleSessionEvent)(w, req)
 	
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -219,9 +222,10 @@ func TestSessionHandler_HandleSessionEvent_MissingSessionID(t *testing.T) {
 	
 	req := httptest.NewRequest(http.MethodPost, "/messages/session", bytes.NewBuffer(payload))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-hook-token")
 	
 	w := httptest.NewRecorder()
-	handler.HandleSessionEvent(w, req)
+	Invoke(handler.HandleSessionEvent)(w, req)
 	
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -257,9 +261,10 @@ func TestSessionHandler_HandleSessionEvent_UnknownEvent(t *testing.T) {
 	
 	req := httptest.NewRequest(http.MethodPost, "/messages/session", bytes.NewBuffer(payload))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-hook-token")
 	
 	w := httptest.NewRecorder()
-	handler.HandleSessionEvent(w, req)
+	Invoke(handler.HandleSessionEvent)(w, req)
 	
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
@@ -299,9 +304,10 @@ func TestSessionHandler_ConversationCreation(t *testing.T) {
 	
 	req := httptest.NewRequest(http.MethodPost, "/messages/session", bytes.NewBuffer(payload))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-hook-token")
 	
 	w := httptest.NewRecorder()
-	handler.HandleSessionEvent(w, req)
+	Invoke(handler.HandleSessionEvent)(w, req)
 	
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
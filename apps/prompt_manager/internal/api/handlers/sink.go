@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/claude-code-template/prompt-manager/internal/events"
+)
+
+// sinkPublishTimeout bounds how long an auxiliary sink (webhook, file) is
+// given to handle a single event. It runs on its own goroutine, off the
+// request path, so this only protects against a sink leaking goroutines
+// forever, not against slowing down the HTTP response.
+const sinkPublishTimeout = 10 * time.Second
+
+// SinkEvent is the normalized, sink-agnostic representation of a
+// prompt/response activity event. It carries plain fields rather than a
+// *database.Message so sinks outside this package (a webhook endpoint, an
+// NDJSON file) don't need to import internal/database just to marshal one.
+type SinkEvent struct {
+	Type           string    `json:"type"`
+	ConversationID int       `json:"conversation_id"`
+	SessionID      string    `json:"session_id"`
+	MessageID      int       `json:"message_id"`
+	Content        string    `json:"content"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// EventSink receives a copy of every prompt/response event a handler
+// processes. SQLite persistence happens before any sink runs and is the
+// source of truth for the HTTP response (its failure is a 500); sinks are
+// fanned out to concurrently afterwards, and a sink error is only logged,
+// never surfaced to the caller.
+type EventSink interface {
+	Publish(ctx context.Context, event SinkEvent) error
+}
+
+// BusSink forwards events onto an events.Bus, so a web UI subscribed to
+// Server.StreamHandler keeps seeing hook-submitted activity now that
+// publishing goes through the EventSink fan-out instead of a direct call.
+// It never returns an error: Bus.Publish can't fail — a lagging subscriber
+// is dropped for that event rather than retried.
+type BusSink struct {
+	Bus *events.Bus
+}
+
+func (s *BusSink) Publish(_ context.Context, event SinkEvent) error {
+	s.Bus.Publish(events.GlobalTopic, event.Type, event)
+	s.Bus.Publish(events.ConversationTopic(event.ConversationID), event.Type, event)
+	return nil
+}
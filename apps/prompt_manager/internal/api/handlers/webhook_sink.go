@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultWebhookMaxRetries is how many additional delivery attempts
+// WebhookSink makes, with exponential backoff, before queueing an event for
+// a later FlushQueue call.
+const DefaultWebhookMaxRetries = 3
+
+// DefaultWebhookBaseDelay is the backoff applied after the first failed
+// attempt; it doubles on each subsequent retry.
+const DefaultWebhookBaseDelay = 200 * time.Millisecond
+
+// DefaultWebhookFlushInterval is how often StartFlushLoop retries the
+// on-disk queue.
+const DefaultWebhookFlushInterval = 1 * time.Minute
+
+// WebhookSink POSTs each event as JSON to a configured URL. Deliveries that
+// exhaust their retries are written under queueDir so a restart, or a later
+// call to FlushQueue, doesn't lose them.
+type WebhookSink struct {
+	url        string
+	queueDir   string
+	client     *http.Client
+	maxRetries int
+	baseDelay  time.Duration
+	logger     Logger
+
+	mu sync.Mutex
+}
+
+// WebhookSinkOption configures a WebhookSink.
+type WebhookSinkOption func(*WebhookSink)
+
+// WithWebhookClient overrides the *http.Client used to deliver events.
+// Defaults to http.DefaultClient.
+func WithWebhookClient(c *http.Client) WebhookSinkOption {
+	return func(ws *WebhookSink) {
+		ws.client = c
+	}
+}
+
+// WithWebhookMaxRetries overrides how many retries are attempted before an
+// event is queued. Defaults to DefaultWebhookMaxRetries.
+func WithWebhookMaxRetries(n int) WebhookSinkOption {
+	return func(ws *WebhookSink) {
+		ws.maxRetries = n
+	}
+}
+
+// WithWebhookBaseDelay overrides the backoff applied after the first failed
+// attempt. Defaults to DefaultWebhookBaseDelay.
+func WithWebhookBaseDelay(d time.Duration) WebhookSinkOption {
+	return func(ws *WebhookSink) {
+		ws.baseDelay = d
+	}
+}
+
+// WithWebhookLogger overrides the logger used to record queued deliveries.
+// Defaults to StdLogger.
+func WithWebhookLogger(l Logger) WebhookSinkOption {
+	return func(ws *WebhookSink) {
+		ws.logger = l
+	}
+}
+
+// NewWebhookSink creates a sink that POSTs events to url, persisting
+// undelivered events under queueDir.
+func NewWebhookSink(url, queueDir string, opts ...WebhookSinkOption) *WebhookSink {
+	ws := &WebhookSink{
+		url:        url,
+		queueDir:   queueDir,
+		client:     http.DefaultClient,
+		maxRetries: DefaultWebhookMaxRetries,
+		baseDelay:  DefaultWebhookBaseDelay,
+		logger:     StdLogger{},
+	}
+	for _, opt := range opts {
+		opt(ws)
+	}
+	return ws
+}
+
+// Publish delivers event to the configured URL, retrying with exponential
+// backoff. If every attempt fails (or ctx is cancelled mid-backoff), the
+// event is queued on disk instead of being dropped, and Publish still
+// returns an error so the caller can log it.
+func (ws *WebhookSink) Publish(ctx context.Context, event SinkEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	var lastErr error
+attempts:
+	for attempt := 0; attempt <= ws.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := ws.baseDelay * time.Duration(1<<(attempt-1))
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				lastErr = ctx.Err()
+				break attempts
+			}
+		}
+
+		if err := ws.deliver(ctx, body); err != nil {
+			lastErr = err
+			continue
+		}
+		return nil
+	}
+
+	if queueErr := ws.enqueue(body); queueErr != nil {
+		return fmt.Errorf("delivery failed (%v) and could not be queued: %w", lastErr, queueErr)
+	}
+	return fmt.Errorf("delivery failed after %d attempts, queued for retry: %w", ws.maxRetries+1, lastErr)
+}
+
+func (ws *WebhookSink) deliver(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ws.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// enqueue persists body under queueDir so FlushQueue can retry it later,
+// surviving a process restart between now and the next successful delivery.
+func (ws *WebhookSink) enqueue(body []byte) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if err := os.MkdirAll(ws.queueDir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d.json", time.Now().UnixNano())
+	return os.WriteFile(filepath.Join(ws.queueDir, name), body, 0o644)
+}
+
+// StartFlushLoop calls FlushQueue once immediately and then every interval,
+// until ctx is done, so queued deliveries left by a past webhook outage (or
+// a restart mid-outage) are retried without an operator having to trigger it
+// by hand. It blocks; callers run it with `go`.
+func (ws *WebhookSink) StartFlushLoop(ctx context.Context, interval time.Duration) {
+	if err := ws.FlushQueue(ctx); err != nil {
+		ws.logger.Log(map[string]interface{}{"op": "webhook_flush_loop", "error": err.Error()})
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ws.FlushQueue(ctx); err != nil {
+				ws.logger.Log(map[string]interface{}{"op": "webhook_flush_loop", "error": err.Error()})
+			}
+		}
+	}
+}
+
+// FlushQueue retries every delivery currently queued under queueDir,
+// removing each file that succeeds. It's called periodically by
+// StartFlushLoop so a webhook outage doesn't lose events permanently; an
+// operator can also invoke it directly for an on-demand retry.
+func (ws *WebhookSink) FlushQueue(ctx context.Context) error {
+	entries, err := os.ReadDir(ws.queueDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to list webhook queue: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(ws.queueDir, entry.Name())
+		body, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if err := ws.deliver(ctx, body); err != nil {
+			ws.logger.Log(map[string]interface{}{"op": "webhook_flush_queue", "file": entry.Name(), "error": err.Error()})
+			continue
+		}
+		os.Remove(path)
+	}
+	return nil
+}
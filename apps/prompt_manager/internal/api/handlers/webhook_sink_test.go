@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookSink_Publish_DeliversOnFirstAttempt(t *testing.T) {
+	var received SinkEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(srv.URL, t.TempDir())
+	event := SinkEvent{Type: "prompt", ConversationID: 1, SessionID: "s1", MessageID: 2, Content: "hi"}
+
+	if err := sink.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if received.Content != "hi" {
+		t.Errorf("Expected delivered event content %q, got %q", "hi", received.Content)
+	}
+}
+
+func TestWebhookSink_Publish_RetriesThenSucceeds(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(srv.URL, t.TempDir(), WithWebhookBaseDelay(time.Millisecond))
+	event := SinkEvent{Type: "prompt", ConversationID: 1, SessionID: "s1"}
+
+	if err := sink.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Expected the sink to succeed after retries, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected exactly 3 attempts, got %d", attempts)
+	}
+}
+
+func TestWebhookSink_Publish_QueuesAfterExhaustingRetries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	queueDir := t.TempDir()
+	sink := NewWebhookSink(srv.URL, queueDir, WithWebhookMaxRetries(1), WithWebhookBaseDelay(time.Millisecond))
+	event := SinkEvent{Type: "prompt", ConversationID: 1, SessionID: "s1"}
+
+	if err := sink.Publish(context.Background(), event); err == nil {
+		t.Fatal("Expected Publish to return an error once retries are exhausted")
+	}
+
+	entries, err := os.ReadDir(queueDir)
+	if err != nil {
+		t.Fatalf("Failed to read queue dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected exactly 1 queued delivery, got %d", len(entries))
+	}
+
+	var queued SinkEvent
+	body, err := os.ReadFile(filepath.Join(queueDir, entries[0].Name()))
+	if err != nil {
+		t.Fatalf("Failed to read queued delivery: %v", err)
+	}
+	if err := json.Unmarshal(body, &queued); err != nil {
+		t.Fatalf("Failed to unmarshal queued delivery: %v", err)
+	}
+	if queued.SessionID != "s1" {
+		t.Errorf("Expected queued delivery to preserve the original event, got %+v", queued)
+	}
+}
+
+func TestWebhookSink_FlushQueue_RetriesAndRemovesDeliveredEntries(t *testing.T) {
+	up := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	queueDir := t.TempDir()
+	sink := NewWebhookSink(srv.URL, queueDir, WithWebhookMaxRetries(0), WithWebhookBaseDelay(time.Millisecond))
+	event := SinkEvent{Type: "prompt", ConversationID: 1, SessionID: "s1"}
+
+	if err := sink.Publish(context.Background(), event); err == nil {
+		t.Fatal("Expected the initial delivery to fail and be queued")
+	}
+
+	up = true
+	if err := sink.FlushQueue(context.Background()); err != nil {
+		t.Fatalf("Expected FlushQueue to succeed, got %v", err)
+	}
+
+	entries, err := os.ReadDir(queueDir)
+	if err != nil {
+		t.Fatalf("Failed to read queue dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected the queue to be empty after a successful flush, got %d entries", len(entries))
+	}
+}
+
+func TestWebhookSink_StartFlushLoop_RetriesQueuedEntriesWithoutManualFlush(t *testing.T) {
+	up := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	queueDir := t.TempDir()
+	sink := NewWebhookSink(srv.URL, queueDir, WithWebhookMaxRetries(0), WithWebhookBaseDelay(time.Millisecond))
+	event := SinkEvent{Type: "prompt", ConversationID: 1, SessionID: "s1"}
+
+	if err := sink.Publish(context.Background(), event); err == nil {
+		t.Fatal("Expected the initial delivery to fail and be queued")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sink.StartFlushLoop(ctx, time.Millisecond)
+
+	up = true
+	deadline := time.Now().Add(time.Second)
+	for {
+		entries, err := os.ReadDir(queueDir)
+		if err != nil {
+			t.Fatalf("Failed to read queue dir: %v", err)
+		}
+		if len(entries) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected StartFlushLoop to drain the queue without a manual FlushQueue call, still have %d entries", len(entries))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
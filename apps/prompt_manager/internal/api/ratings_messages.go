@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/claude-code-template/prompt-manager/internal/models"
+)
+
+// CreateMessageRatingHandler creates a rating against a specific message,
+// as opposed to CreateConversationRatingHandler's conversation-level
+// ratings. It publishes the same rating.updated event, scoped to the
+// message's conversation.
+func (s *Server) CreateMessageRatingHandler(w http.ResponseWriter, r *http.Request) error {
+	messageID, err := intVar(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req struct {
+		Rating  int     `json:"rating"`
+		Comment *string `json:"comment"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return &HTTPError{Msg: "Invalid JSON request body", Code: http.StatusBadRequest}
+	}
+	if req.Rating < 1 || req.Rating > 5 {
+		return &HTTPError{Msg: "rating must be between 1 and 5", Code: http.StatusBadRequest}
+	}
+
+	rating, err := s.db.CreateMessageRating(messageID, req.Rating, req.Comment)
+	if err != nil {
+		return fmt.Errorf("failed to create message rating: %w", err)
+	}
+
+	apiRating := models.Rating{
+		ID:             rating.ID,
+		ConversationID: rating.ConversationID,
+		MessageID:      rating.MessageID,
+		Rating:         rating.Rating,
+		Comment:        rating.Comment,
+		CreatedAt:      rating.CreatedAt,
+		UpdatedAt:      rating.UpdatedAt,
+	}
+
+	s.publishConversationEvent(rating.ConversationID, "rating.updated", apiRating)
+
+	w.WriteHeader(http.StatusCreated)
+	successResponse(w, apiRating, nil)
+	return nil
+}
+
+// GetRatingAnalyticsHandler returns rating stats aggregated by the rated
+// message's conversation working directory, weekday, tool(s) used, and
+// message type.
+func (s *Server) GetRatingAnalyticsHandler(w http.ResponseWriter, r *http.Request) error {
+	analytics, err := s.db.GetRatingAnalytics()
+	if err != nil {
+		return fmt.Errorf("failed to get rating analytics: %w", err)
+	}
+
+	successResponse(w, analytics, nil)
+	return nil
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/claude-code-template/prompt-manager/internal/api/handlers"
+	"github.com/gorilla/mux"
+)
+
+// Handler is an HTTP handler that reports failure by returning an error
+// instead of writing an error response itself. Catch turns that error into
+// the standard APIResponse envelope, so handlers no longer need to repeat
+// `errorResponse(w, ...); return` at every validation step. Unlike
+// handlers.APIHandler, Handler keeps direct access to w, which a few
+// registered handlers (the NDJSON/zip export streams) need in order to
+// write their response body incrementally rather than returning it whole.
+type Handler func(w http.ResponseWriter, r *http.Request) error
+
+// HTTPError is a Handler error that maps directly to an HTTP status code.
+// It's an alias for handlers.HTTPError, not a second type with the same
+// shape, so Catch can reuse handlers.RecoverAndRespond/RespondError instead
+// of re-implementing their panic-recovery and error-to-status translation.
+type HTTPError = handlers.HTTPError
+
+// Catch adapts a Handler to http.HandlerFunc, reusing the same panic
+// recovery and *HTTPError-to-status translation Invoke applies to an
+// APIHandler.
+func Catch(h Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer handlers.RecoverAndRespond(w, r)
+
+		if err := h(w, r); err != nil {
+			handlers.RespondError(w, err)
+		}
+	}
+}
+
+// Router centralizes route registration for one API version mount point
+// (e.g. /api/v1, /api/v2), wrapping a gorilla/mux subrouter so call sites
+// register Handlers instead of raw http.HandlerFuncs.
+type Router struct {
+	mux *mux.Router
+}
+
+// NewRouter mounts a Router under prefix on parent (e.g. "/api/v2").
+func NewRouter(parent *mux.Router, prefix string) *Router {
+	return &Router{mux: parent.PathPrefix(prefix).Subrouter()}
+}
+
+// Use appends middleware to this Router's stack. Middleware registered here
+// only wraps routes mounted on this Router, not sibling version mounts.
+func (rt *Router) Use(mw mux.MiddlewareFunc) {
+	rt.mux.Use(mw)
+}
+
+// Handle registers h for method+path, adapted through Catch.
+func (rt *Router) Handle(method, path string, h Handler) {
+	rt.mux.Handle(path, Catch(h)).Methods(method)
+}
+
+// HandleFunc registers a plain http.HandlerFunc, for handlers that haven't
+// been migrated to the Handler signature yet.
+func (rt *Router) HandleFunc(method, path string, h http.HandlerFunc) {
+	rt.mux.HandleFunc(path, h).Methods(method)
+}
+
+// intVar parses a mux path variable as an int, returning an *HTTPError
+// suitable for a Handler to return directly if it's missing or malformed.
+func intVar(r *http.Request, name string) (int, error) {
+	str, exists := mux.Vars(r)[name]
+	if !exists {
+		return 0, &HTTPError{Msg: name + " is required", Code: http.StatusBadRequest}
+	}
+
+	val, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, &HTTPError{Msg: "Invalid " + name, Code: http.StatusBadRequest}
+	}
+
+	return val, nil
+}
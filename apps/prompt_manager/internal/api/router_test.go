@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCatch_WritesHTTPErrorStatusAndMessage(t *testing.T) {
+	h := Catch(func(w http.ResponseWriter, r *http.Request) error {
+		return &HTTPError{Msg: "not found", Code: http.StatusNotFound}
+	})
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", rec.Code)
+	}
+}
+
+func TestCatch_WrapsGenericErrorAs500(t *testing.T) {
+	h := Catch(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rec.Code)
+	}
+}
+
+func TestCatch_RecoversPanics(t *testing.T) {
+	h := Catch(func(w http.ResponseWriter, r *http.Request) error {
+		panic("kaboom")
+	})
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 after recovering a panic, got %d", rec.Code)
+	}
+}
+
+func TestCatch_NilErrorLeavesHandlerResponseIntact(t *testing.T) {
+	h := Catch(func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusCreated)
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", rec.Code)
+	}
+}
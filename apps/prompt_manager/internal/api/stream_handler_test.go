@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/claude-code-template/prompt-manager/internal/events"
+)
+
+func TestCreateConversationHandler_PublishesConversationCreatedEvent(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	bus := events.NewBus()
+	server := NewServer(db, WithEventBus(bus))
+	sub := bus.Subscribe(events.GlobalTopic, 0)
+	defer sub.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/conversations", strings.NewReader(`{"session_id":"stream-session"}`))
+	rec := httptest.NewRecorder()
+
+	server.CreateConversationHandler(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	select {
+	case ev := <-sub.C:
+		if ev.Type != "conversation.created" {
+			t.Errorf("Expected conversation.created, got %s", ev.Type)
+		}
+	default:
+		t.Fatal("Expected an event to be published on the global topic")
+	}
+}
+
+func TestDeleteConversationHandler_PublishesConversationDeletedEvent(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	conv, err := db.CreateConversation("delete-session", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+
+	bus := events.NewBus()
+	server := NewServer(db, WithEventBus(bus))
+	sub := bus.Subscribe(events.ConversationTopic(conv.ID), 0)
+	defer sub.Close()
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/conversations/"+strconv.Itoa(conv.ID), nil)
+	req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(conv.ID)})
+	rec := httptest.NewRecorder()
+
+	server.DeleteConversationHandler(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	select {
+	case ev := <-sub.C:
+		if ev.Type != "conversation.deleted" {
+			t.Errorf("Expected conversation.deleted, got %s", ev.Type)
+		}
+	default:
+		t.Fatal("Expected an event to be published on the conversation topic")
+	}
+}
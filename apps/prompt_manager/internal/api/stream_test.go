@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/claude-code-template/prompt-manager/internal/database"
+)
+
+func setupTestDB(t *testing.T) *database.DB {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	config := &database.Config{
+		DatabasePath:  dbPath,
+		MigrationsDir: "../../database/migrations",
+	}
+
+	db, err := database.New(config)
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	if err := db.RunMigrations(config.MigrationsDir); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	return db
+}
+
+func TestConvertMessageStream_StreamsInOrderAfterCursor(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	conv, err := db.CreateConversation("stream-session", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+
+	var lastID int
+	for i := 0; i < 3; i++ {
+		msg, err := db.CreateMessage(conv.ID, "prompt", "message", nil, nil)
+		if err != nil {
+			t.Fatalf("Failed to create message: %v", err)
+		}
+		lastID = msg.ID
+	}
+
+	// The first message is before our cursor, so only the last two should
+	// stream back.
+	firstMessage, err := db.ListMessagesAfter(conv.ID, 0, 1)
+	if err != nil {
+		t.Fatalf("Failed to list first message: %v", err)
+	}
+	var cursor int
+	for msg := range ConvertMessageStream(context.Background(), firstMessage) {
+		cursor = msg.ID
+	}
+
+	rows, err := db.ListMessagesAfter(conv.ID, cursor, 10)
+	if err != nil {
+		t.Fatalf("Failed to list messages after cursor: %v", err)
+	}
+
+	var ids []int
+	for msg := range ConvertMessageStream(context.Background(), rows) {
+		ids = append(ids, msg.ID)
+	}
+
+	if len(ids) != 2 {
+		t.Fatalf("Expected 2 messages after the cursor, got %d", len(ids))
+	}
+	if ids[len(ids)-1] != lastID {
+		t.Errorf("Expected last streamed message ID %d, got %d", lastID, ids[len(ids)-1])
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Errorf("Expected ascending IDs, got %v", ids)
+		}
+	}
+}
+
+func TestConvertMessageStream_CancelUnblocksProducerAndClosesRows(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	conv, err := db.CreateConversation("stream-cancel-session", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := db.CreateMessage(conv.ID, "prompt", "message", nil, nil); err != nil {
+			t.Fatalf("Failed to create message: %v", err)
+		}
+	}
+
+	rows, err := db.ListMessagesAfter(conv.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to list messages: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := ConvertMessageStream(ctx, rows)
+
+	// Take exactly one message, as a consumer that stops draining early
+	// (e.g. a disconnected client) would, then cancel instead of continuing
+	// to range over the channel.
+	<-stream
+	cancel()
+
+	select {
+	case _, ok := <-stream:
+		if ok {
+			t.Fatal("Expected the stream to close after cancellation without yielding more messages")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the producer goroutine to exit (closing the channel) once ctx was cancelled, instead it stayed blocked")
+	}
+
+	// rows is closed by the producer's deferred rows.Close(); a second Next
+	// call after Close returns false rather than panicking, so this just
+	// confirms the producer actually reached its defer instead of hanging.
+	if rows.Next() {
+		t.Error("Expected rows to have been closed by the cancelled producer")
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// AddConversationTagHandler attaches a tag to a conversation, creating the
+// tag if it doesn't already exist.
+func (s *Server) AddConversationTagHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := intVar(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var req struct {
+		Tag string `json:"tag"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return &HTTPError{Msg: "Invalid JSON request body", Code: http.StatusBadRequest}
+	}
+	if req.Tag == "" {
+		return &HTTPError{Msg: "tag is required", Code: http.StatusBadRequest}
+	}
+
+	if err := s.db.AddConversationTag(id, req.Tag); err != nil {
+		return fmt.Errorf("failed to add tag: %w", err)
+	}
+
+	tags, err := s.db.GetConversationTags(id)
+	if err != nil {
+		return fmt.Errorf("failed to load tags: %w", err)
+	}
+
+	successResponse(w, tags, nil)
+	return nil
+}
+
+// RemoveConversationTagHandler detaches a tag from a conversation. Removing
+// a tag that isn't attached is a no-op, not an error.
+func (s *Server) RemoveConversationTagHandler(w http.ResponseWriter, r *http.Request) error {
+	id, err := intVar(r, "id")
+	if err != nil {
+		return err
+	}
+
+	tag, exists := mux.Vars(r)["tag"]
+	if !exists || tag == "" {
+		return &HTTPError{Msg: "tag is required", Code: http.StatusBadRequest}
+	}
+
+	if err := s.db.RemoveConversationTag(id, tag); err != nil {
+		return fmt.Errorf("failed to remove tag: %w", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// ListTagsHandler returns every tag name in use.
+func (s *Server) ListTagsHandler(w http.ResponseWriter, r *http.Request) error {
+	tags, err := s.db.ListTags()
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	successResponse(w, tags, nil)
+	return nil
+}
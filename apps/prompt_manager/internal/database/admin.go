@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// errUnsupportedOnDriver is returned by the admin operations below that are
+// written directly against mattn/go-sqlite3 rather than the driver
+// abstraction, because database/sql has no portable equivalent (SQLite's
+// online backup API, WAL checkpointing, integrity_check).
+func errUnsupportedOnDriver(op, name string) error {
+	return fmt.Errorf("%s is only supported on the sqlite driver, not %q", op, name)
+}
+
+// Backup takes an online backup of the database to dst using SQLite's
+// backup API, so a snapshot can be taken while the server keeps serving
+// reads and writes. It steps the backup to completion in one call; callers
+// wanting a progress callback or a cooperative multi-step backup should call
+// sqlite3.SQLiteConn.Backup directly instead.
+func (db *DB) Backup(ctx context.Context, dst string) error {
+	if db.driver.Name() != "sqlite" {
+		return errUnsupportedOnDriver("Backup", db.driver.Name())
+	}
+
+	srcAbs, err := filepath.Abs(db.path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve database path: %w", err)
+	}
+	dstAbs, err := filepath.Abs(dst)
+	if err != nil {
+		return fmt.Errorf("failed to resolve backup destination: %w", err)
+	}
+	if srcAbs == dstAbs {
+		return fmt.Errorf("backup destination must not be the live database file")
+	}
+
+	destDB, err := sql.Open("sqlite3", dst)
+	if err != nil {
+		return fmt.Errorf("failed to open backup destination: %w", err)
+	}
+	defer destDB.Close()
+
+	// A dedicated connection to db.path, rather than one borrowed from
+	// db.conn's pool, so the backup doesn't hold the single connection
+	// SetMaxOpenConns(1) allows db.conn and serialize every other request
+	// behind it — that would defeat the point of an "online" backup. SQLite
+	// (under the WAL journal mode DefaultConfig selects) supports multiple
+	// readers against one file concurrently.
+	srcDB, err := sql.Open("sqlite3", db.path)
+	if err != nil {
+		return fmt.Errorf("failed to open backup source: %w", err)
+	}
+	defer srcDB.Close()
+
+	srcConn, err := srcDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire source connection: %w", err)
+	}
+	defer srcConn.Close()
+
+	destConn, err := destDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire destination connection: %w", err)
+	}
+	defer destConn.Close()
+
+	var backupErr error
+	err = destConn.Raw(func(destDriverConn interface{}) error {
+		return srcConn.Raw(func(srcDriverConn interface{}) error {
+			backup, err := destDriverConn.(*sqlite3.SQLiteConn).Backup("main", srcDriverConn.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return fmt.Errorf("failed to start backup: %w", err)
+			}
+			defer backup.Close()
+
+			// -1 steps through every remaining page in one call rather than
+			// a bounded chunk at a time; fine at prompt-manager's scale.
+			done, err := backup.Step(-1)
+			if err != nil {
+				backupErr = fmt.Errorf("failed to run backup: %w", err)
+				return nil
+			}
+			if !done {
+				backupErr = fmt.Errorf("backup did not complete in a single step")
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return backupErr
+}
+
+// Vacuum rebuilds the database file to reclaim space left by deleted rows.
+// It holds an exclusive lock for its duration, so callers should run it
+// during a maintenance window rather than on the hot path.
+func (db *DB) Vacuum() error {
+	if _, err := db.conn.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	return nil
+}
+
+// IntegrityCheck runs SQLite's integrity_check pragma and returns its
+// reported problems. A nil (or empty) slice means the check passed; a
+// single-element slice containing "ok" is never returned, as that's already
+// folded into success.
+func (db *DB) IntegrityCheck() ([]string, error) {
+	if db.driver.Name() != "sqlite" {
+		return nil, errUnsupportedOnDriver("IntegrityCheck", db.driver.Name())
+	}
+
+	rows, err := db.conn.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run integrity_check: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("failed to read integrity_check result: %w", err)
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read integrity_check results: %w", err)
+	}
+	return problems, nil
+}
+
+// Checkpoint runs a WAL checkpoint, folding the -wal file back into the main
+// database file. TRUNCATE also shrinks the -wal file back to zero bytes
+// afterward, rather than just leaving it at its high-water mark.
+func (db *DB) Checkpoint() error {
+	if db.driver.Name() != "sqlite" {
+		return errUnsupportedOnDriver("Checkpoint", db.driver.Name())
+	}
+	if _, err := db.conn.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("failed to checkpoint WAL: %w", err)
+	}
+	return nil
+}
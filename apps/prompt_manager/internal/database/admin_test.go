@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func newAdminTestDB(t *testing.T) *DB {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := New(&Config{DatabasePath: dbPath, JournalMode: "WAL", Synchronous: "NORMAL"})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.conn.Exec("CREATE TABLE conversations (id INTEGER PRIMARY KEY, session_id TEXT)"); err != nil {
+		t.Fatalf("Failed to create conversations table: %v", err)
+	}
+	if _, err := db.conn.Exec("CREATE TABLE messages (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("Failed to create messages table: %v", err)
+	}
+	if _, err := db.conn.Exec("CREATE TABLE ratings (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("Failed to create ratings table: %v", err)
+	}
+	if _, err := db.conn.Exec("INSERT INTO conversations (session_id) VALUES ('s1')"); err != nil {
+		t.Fatalf("Failed to insert fixture row: %v", err)
+	}
+
+	return db
+}
+
+func TestBackup_CopiesDataToDestination(t *testing.T) {
+	db := newAdminTestDB(t)
+
+	dst := filepath.Join(t.TempDir(), "backup.db")
+	if err := db.Backup(context.Background(), dst); err != nil {
+		t.Fatalf("Failed to back up database: %v", err)
+	}
+
+	backupDB, err := New(&Config{DatabasePath: dst})
+	if err != nil {
+		t.Fatalf("Failed to open backup: %v", err)
+	}
+	defer backupDB.Close()
+
+	var count int
+	if err := backupDB.conn.QueryRow("SELECT COUNT(*) FROM conversations").Scan(&count); err != nil {
+		t.Fatalf("Failed to count conversations in backup: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected the backup to contain 1 conversation row, got %d", count)
+	}
+}
+
+func TestVacuum_RunsWithoutError(t *testing.T) {
+	db := newAdminTestDB(t)
+
+	if err := db.Vacuum(); err != nil {
+		t.Fatalf("Failed to vacuum: %v", err)
+	}
+}
+
+func TestIntegrityCheck_ReportsNoProblemsOnAHealthyDatabase(t *testing.T) {
+	db := newAdminTestDB(t)
+
+	problems, err := db.IntegrityCheck()
+	if err != nil {
+		t.Fatalf("Failed to run integrity check: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("Expected no integrity problems, got %v", problems)
+	}
+}
+
+func TestCheckpoint_RunsWithoutError(t *testing.T) {
+	db := newAdminTestDB(t)
+
+	if err := db.Checkpoint(); err != nil {
+		t.Fatalf("Failed to checkpoint: %v", err)
+	}
+}
+
+func TestStats_ReportsPageCountAndWALSize(t *testing.T) {
+	db := newAdminTestDB(t)
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if _, ok := stats["page_count"]; !ok {
+		t.Error("Expected stats to include page_count")
+	}
+	if _, ok := stats["wal_size_bytes"]; !ok {
+		t.Error("Expected stats to include wal_size_bytes")
+	}
+}
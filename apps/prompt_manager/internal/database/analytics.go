@@ -0,0 +1,131 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RatingBreakdown is one grouping bucket of RatingAnalytics: a dimension
+// value (e.g. a working directory or weekday name) with the count and mean
+// of the ratings attributed to it.
+type RatingBreakdown struct {
+	Key           string  `json:"key"`
+	Count         int     `json:"count"`
+	AverageRating float64 `json:"average_rating"`
+}
+
+// RatingAnalytics aggregates every message-level rating across several
+// dimensions pulled from the rated message and its conversation.
+type RatingAnalytics struct {
+	ByWorkingDirectory []RatingBreakdown `json:"by_working_directory"`
+	ByWeekday          []RatingBreakdown `json:"by_weekday"`
+	ByToolUsed         []RatingBreakdown `json:"by_tool_used"`
+	ByMessageType      []RatingBreakdown `json:"by_message_type"`
+}
+
+// toolCallName is the minimal shape needed to attribute a rating to the
+// tool(s) named in a message's ToolCalls JSON.
+type toolCallName struct {
+	Name string `json:"name"`
+}
+
+type ratingBucket struct {
+	count int
+	sum   int
+}
+
+// GetRatingAnalytics aggregates every rating with a non-nil message_id by
+// the rated message's conversation working directory, the weekday the
+// message was sent, each tool named in its ToolCalls, and its message
+// type.
+func (db *DB) GetRatingAnalytics() (*RatingAnalytics, error) {
+	rows, err := db.conn.Query(`
+		SELECT r.rating, m.message_type, m.tool_calls, m.timestamp, c.working_directory
+		FROM ratings r
+		JOIN messages m ON m.id = r.message_id
+		JOIN conversations c ON c.id = m.conversation_id
+		WHERE r.message_id IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rating analytics: %w", err)
+	}
+	defer rows.Close()
+
+	byWorkingDirectory := map[string]*ratingBucket{}
+	byWeekday := map[string]*ratingBucket{}
+	byToolUsed := map[string]*ratingBucket{}
+	byMessageType := map[string]*ratingBucket{}
+
+	for rows.Next() {
+		var (
+			rating           int
+			messageType      string
+			toolCalls        *string
+			timestamp        time.Time
+			workingDirectory *string
+		)
+		if err := rows.Scan(&rating, &messageType, &toolCalls, &timestamp, &workingDirectory); err != nil {
+			return nil, fmt.Errorf("failed to scan rating analytics row: %w", err)
+		}
+
+		wd := "unknown"
+		if workingDirectory != nil && *workingDirectory != "" {
+			wd = *workingDirectory
+		}
+		addToRatingBucket(byWorkingDirectory, wd, rating)
+		addToRatingBucket(byWeekday, timestamp.Weekday().String(), rating)
+		addToRatingBucket(byMessageType, messageType, rating)
+
+		if toolCalls != nil && *toolCalls != "" {
+			var calls []toolCallName
+			if err := json.Unmarshal([]byte(*toolCalls), &calls); err == nil {
+				for _, call := range calls {
+					if call.Name != "" {
+						addToRatingBucket(byToolUsed, call.Name, rating)
+					}
+				}
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rating analytics rows: %w", err)
+	}
+
+	return &RatingAnalytics{
+		ByWorkingDirectory: ratingBreakdownsFrom(byWorkingDirectory),
+		ByWeekday:          ratingBreakdownsFrom(byWeekday),
+		ByToolUsed:         ratingBreakdownsFrom(byToolUsed),
+		ByMessageType:      ratingBreakdownsFrom(byMessageType),
+	}, nil
+}
+
+func addToRatingBucket(buckets map[string]*ratingBucket, key string, rating int) {
+	bucket, ok := buckets[key]
+	if !ok {
+		bucket = &ratingBucket{}
+		buckets[key] = bucket
+	}
+	bucket.count++
+	bucket.sum += rating
+}
+
+func ratingBreakdownsFrom(buckets map[string]*ratingBucket) []RatingBreakdown {
+	keys := make([]string, 0, len(buckets))
+	for k := range buckets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	breakdowns := make([]RatingBreakdown, len(keys))
+	for i, k := range keys {
+		bucket := buckets[k]
+		breakdowns[i] = RatingBreakdown{
+			Key:           k,
+			Count:         bucket.count,
+			AverageRating: float64(bucket.sum) / float64(bucket.count),
+		}
+	}
+	return breakdowns
+}
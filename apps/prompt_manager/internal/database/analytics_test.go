@@ -0,0 +1,38 @@
+package database
+
+import "testing"
+
+func TestGetRatingAnalytics_GroupsByMessageTypeAndTool(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	conv, err := db.CreateConversation("analytics-session", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+
+	toolCalls := `[{"name":"bash"}]`
+	msg, err := db.CreateMessage(conv.ID, "response", "ran a command", &toolCalls, nil)
+	if err != nil {
+		t.Fatalf("Failed to create message: %v", err)
+	}
+
+	if _, err := db.CreateMessageRating(msg.ID, 4, nil); err != nil {
+		t.Fatalf("Failed to create message rating: %v", err)
+	}
+
+	analytics, err := db.GetRatingAnalytics()
+	if err != nil {
+		t.Fatalf("GetRatingAnalytics failed: %v", err)
+	}
+
+	if len(analytics.ByMessageType) != 1 || analytics.ByMessageType[0].Key != "response" {
+		t.Errorf("Expected one response-type bucket, got %+v", analytics.ByMessageType)
+	}
+	if len(analytics.ByToolUsed) != 1 || analytics.ByToolUsed[0].Key != "bash" {
+		t.Errorf("Expected one bash-tool bucket, got %+v", analytics.ByToolUsed)
+	}
+	if analytics.ByToolUsed[0].AverageRating != 4 {
+		t.Errorf("Expected average rating 4 for bash, got %v", analytics.ByToolUsed[0].AverageRating)
+	}
+}
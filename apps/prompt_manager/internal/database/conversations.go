@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// GetConversationBySessionID looks up the conversation for sessionID using
+// the unique index on conversations.session_id, returning found=false
+// (rather than an error) when no conversation exists yet for that session.
+// ctx bounds the query so a caller on a request deadline (see
+// handlers.WithDeadline) stops waiting on the pooled connection instead of
+// pinning it past the HTTP response.
+func (db *DB) GetConversationBySessionID(ctx context.Context, sessionID string) (*Conversation, bool, error) {
+	row := db.conn.QueryRowContext(ctx, db.Rebind(`
+		SELECT id, session_id, title, created_at, updated_at, prompt_count, total_characters, working_directory, transcript_path
+		FROM conversations
+		WHERE session_id = ?
+	`), sessionID)
+
+	conv, err := scanConversationRow(row)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get conversation by session_id: %w", err)
+	}
+
+	return conv, true, nil
+}
+
+// GetOrCreateConversationBySessionID returns the existing conversation for
+// sessionID, creating one if none exists. The insert and the read-back run
+// in a single transaction using INSERT ... ON CONFLICT(session_id) DO
+// NOTHING, so two concurrent hooks for the same session cannot race each
+// other into creating duplicate conversation rows. created reports whether
+// this call was the one that inserted the row, so callers can publish a
+// conversation.created event exactly once.
+//
+// ctx bounds the whole transaction: BeginTx fails fast if ctx is already
+// done, and a deadline that fires mid-transaction (see handlers.WithDeadline)
+// aborts the pending Exec/QueryRow instead of holding the connection open
+// after the HTTP handler has already given up and returned a 504.
+func (db *DB) GetOrCreateConversationBySessionID(ctx context.Context, sessionID string, workingDirectory, transcriptPath *string) (conv *Conversation, created bool, err error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, db.Rebind(`
+		INSERT INTO conversations (session_id, working_directory, transcript_path)
+		VALUES (?, ?, ?)
+		`+db.driver.Upsert("session_id", nil)+`
+	`), sessionID, workingDirectory, transcriptPath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to insert conversation: %w", err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check insert result: %w", err)
+	}
+
+	row := tx.QueryRowContext(ctx, db.Rebind(`
+		SELECT id, session_id, title, created_at, updated_at, prompt_count, total_characters, working_directory, transcript_path
+		FROM conversations
+		WHERE session_id = ?
+	`), sessionID)
+
+	conv, err = scanConversationRow(row)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load conversation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return conv, rowsAffected > 0, nil
+}
+
+// ConversationWithStats is a Conversation annotated with the aggregate
+// rating/tag join ListConversationsFiltered performs, for callers (e.g.
+// ConvertConversationWithStats) that need both without a second round trip.
+type ConversationWithStats struct {
+	Conversation
+	AverageRating *float64
+	Tags          []string
+}
+
+// ListConversationsFiltered returns conversations filtered by an optional
+// tag name and/or minimum average rating, in addition to ListConversations'
+// limit/offset pagination. An empty tag or nil minAvgRating skips that
+// filter. Each result carries its average rating and tags, joined in the
+// same query rather than fetched per-conversation.
+func (db *DB) ListConversationsFiltered(limit, offset int, tag string, minAvgRating *float64) ([]ConversationWithStats, error) {
+	query := `
+		SELECT
+			c.id, c.session_id, c.title, c.created_at, c.updated_at, c.prompt_count, c.total_characters, c.working_directory, c.transcript_path,
+			(SELECT AVG(r.rating) FROM ratings r WHERE r.conversation_id = c.id),
+			(SELECT GROUP_CONCAT(t.name, ',') FROM tags t JOIN conversation_tags ct ON ct.tag_id = t.id WHERE ct.conversation_id = c.id)
+		FROM conversations c
+	`
+
+	var conditions []string
+	var args []interface{}
+
+	if tag != "" {
+		query += `
+			JOIN conversation_tags ct ON ct.conversation_id = c.id
+			JOIN tags t ON t.id = ct.tag_id
+		`
+		conditions = append(conditions, "t.name = ?")
+		args = append(args, tag)
+	}
+
+	if minAvgRating != nil {
+		conditions = append(conditions, `(SELECT AVG(r.rating) FROM ratings r WHERE r.conversation_id = c.id) >= ?`)
+		args = append(args, *minAvgRating)
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY c.id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := db.conn.Query(db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list filtered conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var conversations []ConversationWithStats
+	for rows.Next() {
+		var conv ConversationWithStats
+		var avgRating sql.NullFloat64
+		var tagList sql.NullString
+		if err := rows.Scan(
+			&conv.ID, &conv.SessionID, &conv.Title, &conv.CreatedAt, &conv.UpdatedAt,
+			&conv.PromptCount, &conv.TotalCharacters, &conv.WorkingDirectory, &conv.TranscriptPath,
+			&avgRating, &tagList,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan filtered conversation: %w", err)
+		}
+		if avgRating.Valid {
+			conv.AverageRating = &avgRating.Float64
+		}
+		if tagList.Valid && tagList.String != "" {
+			conv.Tags = strings.Split(tagList.String, ",")
+		}
+		conversations = append(conversations, conv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return conversations, nil
+}
+
+func scanConversationRow(row *sql.Row) (*Conversation, error) {
+	var conv Conversation
+	if err := row.Scan(
+		&conv.ID,
+		&conv.SessionID,
+		&conv.Title,
+		&conv.CreatedAt,
+		&conv.UpdatedAt,
+		&conv.PromptCount,
+		&conv.TotalCharacters,
+		&conv.WorkingDirectory,
+		&conv.TranscriptPath,
+	); err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
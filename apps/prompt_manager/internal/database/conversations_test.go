@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func setupTestDB(t *testing.T) *DB {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	config := &Config{
+		DatabasePath:  dbPath,
+		MigrationsDir: "../../database/migrations",
+	}
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	if err := db.RunMigrations(config.MigrationsDir); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	return db
+}
+
+func TestGetConversationBySessionID_NotFound(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	_, found, err := db.GetConversationBySessionID(context.Background(), "missing-session")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if found {
+		t.Error("Expected found to be false for an unknown session_id")
+	}
+}
+
+func TestGetOrCreateConversationBySessionID_CreatesThenReuses(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	workingDir := "/test/dir"
+	conv1, created1, err := db.GetOrCreateConversationBySessionID(context.Background(), "session-1", &workingDir, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+	if !created1 {
+		t.Error("Expected the first call to report created=true")
+	}
+
+	conv2, created2, err := db.GetOrCreateConversationBySessionID(context.Background(), "session-1", &workingDir, nil)
+	if err != nil {
+		t.Fatalf("Failed to get conversation: %v", err)
+	}
+	if created2 {
+		t.Error("Expected the second call to report created=false")
+	}
+
+	if conv1.ID != conv2.ID {
+		t.Errorf("Expected same conversation ID for repeated calls, got %d and %d", conv1.ID, conv2.ID)
+	}
+}
+
+func TestGetOrCreateConversationBySessionID_ConcurrentCallsCreateOneRow(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	const concurrency = 10
+	var wg sync.WaitGroup
+	ids := make([]int, concurrency)
+	errs := make([]error, concurrency)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conv, _, err := db.GetOrCreateConversationBySessionID(context.Background(), "concurrent-session", nil, nil)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			ids[i] = conv.ID
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("goroutine %d failed: %v", i, err)
+		}
+	}
+
+	for i, id := range ids {
+		if id != ids[0] {
+			t.Errorf("Expected all goroutines to observe the same conversation ID, got %d and %d", ids[0], id)
+		}
+	}
+
+	var count int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM conversations WHERE session_id = ?", "concurrent-session").Scan(&count); err != nil {
+		t.Fatalf("Failed to count conversations: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected exactly 1 conversation row, got %d", count)
+	}
+}
+
+// TestGetOrCreateConversationBySessionID_ContextDeadlineStopsTheQuery stands
+// in for handlers.WithDeadline racing a slow DB call: a context that is
+// already past its deadline must fail BeginTx/QueryRowContext immediately
+// instead of running the transaction to completion, so an abandoned request
+// can't keep holding the single pooled connection (SetMaxOpenConns(1)) after
+// its HTTP handler has already returned a 504.
+func TestGetOrCreateConversationBySessionID_ContextDeadlineStopsTheQuery(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	time.Sleep(time.Millisecond)
+
+	_, _, err := db.GetOrCreateConversationBySessionID(ctx, "deadline-session", nil, nil)
+	if err == nil {
+		t.Fatal("Expected an error from a context that is already past its deadline")
+	}
+
+	var count int
+	if scanErr := db.conn.QueryRow("SELECT COUNT(*) FROM conversations WHERE session_id = ?", "deadline-session").Scan(&count); scanErr != nil {
+		t.Fatalf("Failed to count conversations: %v", scanErr)
+	}
+	if count != 0 {
+		t.Errorf("Expected the transaction to have been aborted rather than committed, found %d rows", count)
+	}
+}
@@ -3,23 +3,60 @@ package database
 import (
 	"database/sql"
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"os"
 	"path/filepath"
 
+	"github.com/claude-code-template/prompt-manager/database/driver"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 // DB wraps the database connection with additional functionality
 type DB struct {
-	conn *sql.DB
-	path string
+	conn         *sql.DB
+	path         string
+	driver       driver.Driver
+	migrationsFS fs.FS
+	autoMigrate  bool
 }
 
 // Config holds database configuration
 type Config struct {
 	DatabasePath string
 	MigrationsDir string
+
+	// Driver selects the SQL dialect: "sqlite" (the default, used when
+	// empty) or "postgres". DatabasePath is interpreted accordingly — a
+	// filesystem path for sqlite, a connection string/DSN for postgres.
+	Driver string
+
+	// MigrationsFS, if set, is an embedded filesystem (e.g. an embed.FS
+	// rooted at the migrations directory via fs.Sub) that a deployment can
+	// pass to DB.RunMigrationsFS so the binary doesn't need a sibling
+	// database/migrations directory on disk. New stores it on the returned
+	// DB (in place of os.DirFS(MigrationsDir)) as the filesystem that
+	// CheckCompatibility auto-migrates from and that MigrateTo, Rollback,
+	// Redo, and Status read, until RunMigrationsFS replaces it.
+	MigrationsFS fs.FS
+
+	// AutoMigrate lets DB.CheckCompatibility apply pending migrations
+	// itself when the database is behind this binary's SchemaVersion,
+	// instead of refusing to start. Leave false in production so migrations
+	// only run when an operator explicitly asks for them.
+	AutoMigrate bool
+
+	// JournalMode sets SQLite's journal_mode pragma. DefaultConfig sets it
+	// to "WAL" so the many small writes a stream of hook events produces
+	// don't serialize readers behind writers; left empty, New does not set
+	// the pragma. Ignored on Postgres.
+	JournalMode string
+
+	// Synchronous sets SQLite's synchronous pragma. DefaultConfig sets it to
+	// "NORMAL", which is safe under WAL (only a whole-machine power loss, not
+	// just an application crash, can lose the last commit) and considerably
+	// cheaper than "FULL"; left empty, New does not set the pragma. Ignored
+	// on Postgres.
+	Synchronous string
 }
 
 // DefaultConfig returns default database configuration
@@ -27,25 +64,37 @@ func DefaultConfig() *Config {
 	return &Config{
 		DatabasePath:  ".claude/apps/prompt_manager/database/prompts.db",
 		MigrationsDir: "database/migrations",
+		JournalMode:   "WAL",
+		Synchronous:   "NORMAL",
 	}
 }
 
 // New creates a new database connection
 func New(config *Config) (*DB, error) {
-	// Ensure database directory exists
-	dir := filepath.Dir(config.DatabasePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	d, err := driver.For(config.Driver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select database driver: %w", err)
+	}
+
+	// SQLite's DatabasePath is a file on disk that needs its parent
+	// directory to exist; Postgres's is a connection string with nothing on
+	// the local filesystem to create.
+	if d.Name() == "sqlite" {
+		dir := filepath.Dir(config.DatabasePath)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
 	}
 
 	// Open database connection
-	conn, err := sql.Open("sqlite3", config.DatabasePath)
+	conn, err := sql.Open(d.DriverName(), config.DatabasePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Configure SQLite connection
-	conn.SetMaxOpenConns(1) // SQLite works best with single connection
+	// Configure connection. SQLite works best with a single connection;
+	// harmless at prompt-manager's scale on Postgres too.
+	conn.SetMaxOpenConns(1)
 	conn.SetMaxIdleConns(1)
 
 	// Test connection
@@ -54,20 +103,60 @@ func New(config *Config) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	// Enable foreign keys
-	if _, err := conn.Exec("PRAGMA foreign_keys = ON"); err != nil {
+	// Enable foreign keys (a no-op on Postgres, which always enforces them)
+	if err := d.EnableForeignKeys(conn); err != nil {
 		conn.Close()
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 	}
 
+	// WAL plus synchronous=NORMAL let concurrent hook writes avoid fsyncing
+	// on every commit, at the cost of a checkpoint step (see Checkpoint)
+	// reclaiming the -wal file periodically. Meaningless on Postgres, and
+	// left unset (rather than defaulted here) so existing tests that build a
+	// bare &Config{} keep getting SQLite's default rollback journal.
+	if d.Name() == "sqlite" {
+		if config.JournalMode != "" {
+			if _, err := conn.Exec("PRAGMA journal_mode = " + config.JournalMode); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("failed to set journal_mode: %w", err)
+			}
+		}
+		if config.Synchronous != "" {
+			if _, err := conn.Exec("PRAGMA synchronous = " + config.Synchronous); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("failed to set synchronous: %w", err)
+			}
+		}
+	}
+
+	// CheckCompatibility needs a migrations filesystem to compare (and,
+	// with AutoMigrate, apply from) before RunMigrations/RunMigrationsFS
+	// has necessarily been called, so dev mode's plain directory is wrapped
+	// here too rather than only accepting an explicit MigrationsFS.
+	migrationsFS := config.MigrationsFS
+	if migrationsFS == nil && config.MigrationsDir != "" {
+		migrationsFS = os.DirFS(config.MigrationsDir)
+	}
+
 	db := &DB{
-		conn: conn,
-		path: config.DatabasePath,
+		conn:         conn,
+		path:         config.DatabasePath,
+		driver:       d,
+		migrationsFS: migrationsFS,
+		autoMigrate:  config.AutoMigrate,
 	}
 
 	return db, nil
 }
 
+// Rebind rewrites a query written with `?` placeholders into db's driver's
+// native placeholder syntax. Repository methods call this on every query
+// string before executing it, so `?` vs $1 is handled in one place instead
+// of forking each query per dialect.
+func (db *DB) Rebind(query string) string {
+	return db.driver.Rebind(query)
+}
+
 // Close closes the database connection
 func (db *DB) Close() error {
 	if db.conn != nil {
@@ -81,71 +170,6 @@ func (db *DB) Conn() *sql.DB {
 	return db.conn
 }
 
-// RunMigrations executes database migrations from the migrations directory
-func (db *DB) RunMigrations(migrationsDir string) error {
-	// Create migrations table if it doesn't exist
-	createMigrationsTable := `
-	CREATE TABLE IF NOT EXISTS schema_migrations (
-		version TEXT PRIMARY KEY,
-		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);`
-	
-	if _, err := db.conn.Exec(createMigrationsTable); err != nil {
-		return fmt.Errorf("failed to create migrations table: %w", err)
-	}
-
-	// Find migration files
-	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.up.sql"))
-	if err != nil {
-		return fmt.Errorf("failed to find migration files: %w", err)
-	}
-
-	for _, file := range files {
-		version := extractVersionFromFilename(file)
-		
-		// Check if migration already applied
-		var count int
-		err := db.conn.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", version).Scan(&count)
-		if err != nil {
-			return fmt.Errorf("failed to check migration status: %w", err)
-		}
-		
-		if count > 0 {
-			continue // Skip already applied migration
-		}
-
-		// Read and execute migration
-		content, err := ioutil.ReadFile(file)
-		if err != nil {
-			return fmt.Errorf("failed to read migration file %s: %w", file, err)
-		}
-
-		tx, err := db.conn.Begin()
-		if err != nil {
-			return fmt.Errorf("failed to begin migration transaction: %w", err)
-		}
-
-		if _, err := tx.Exec(string(content)); err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to execute migration %s: %w", file, err)
-		}
-
-		// Mark migration as applied
-		if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", version); err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to record migration %s: %w", file, err)
-		}
-
-		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("failed to commit migration %s: %w", file, err)
-		}
-
-		fmt.Printf("Applied migration: %s\n", version)
-	}
-
-	return nil
-}
-
 // Health checks database connectivity and returns status
 func (db *DB) Health() error {
 	if db.conn == nil {
@@ -188,15 +212,36 @@ func (db *DB) Stats() (map[string]interface{}, error) {
 		stats["database_size_bytes"] = info.Size()
 	}
 
-	return stats, nil
-}
-
-// extractVersionFromFilename extracts version number from migration filename
-// e.g., "001_initial_schema.up.sql" -> "001"
-func extractVersionFromFilename(filename string) string {
-	base := filepath.Base(filename)
-	if len(base) >= 3 {
-		return base[:3]
+	// Schema version/dirty flag, so an operator can spot an old replica
+	// (schema_version behind SchemaVersion) or an interrupted migration
+	// (schema_dirty) without reaching for the schema_migrations table.
+	highest, dirty, err := db.migrationsSummary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize schema_migrations: %w", err)
+	}
+	stats["schema_version"] = highest
+	stats["schema_dirty"] = dirty
+
+	// Page count and WAL file size, so an operator can see VACUUM/Checkpoint
+	// are actually worth running without shelling into sqlite3 directly.
+	// Both are SQLite-specific; left out of stats entirely on Postgres.
+	if db.driver.Name() == "sqlite" {
+		var pageCount int
+		if err := db.conn.QueryRow("PRAGMA page_count").Scan(&pageCount); err != nil {
+			return nil, fmt.Errorf("failed to read page_count: %w", err)
+		}
+		stats["page_count"] = pageCount
+
+		info, err := os.Stat(db.path + "-wal")
+		switch {
+		case err == nil:
+			stats["wal_size_bytes"] = info.Size()
+		case os.IsNotExist(err):
+			stats["wal_size_bytes"] = int64(0)
+		default:
+			return nil, fmt.Errorf("failed to stat WAL file: %w", err)
+		}
 	}
-	return base
+
+	return stats, nil
 }
\ No newline at end of file
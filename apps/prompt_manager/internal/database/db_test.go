@@ -0,0 +1,26 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNew_UnknownDriverErrors(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	if _, err := New(&Config{DatabasePath: dbPath, Driver: "mysql"}); err == nil {
+		t.Fatal("Expected New to error for an unrecognized driver")
+	}
+}
+
+func TestNew_EmptyDriverDefaultsToSQLite(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := New(&Config{DatabasePath: dbPath})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if got := db.Rebind("SELECT * FROM t WHERE a = ?"); got != "SELECT * FROM t WHERE a = ?" {
+		t.Errorf("Expected the default driver's Rebind to pass placeholders through unchanged, got %q", got)
+	}
+}
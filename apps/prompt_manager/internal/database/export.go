@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExportRecord bundles a conversation with its full message and rating
+// history for backup/migration, one JSON value per line of an export.
+type ExportRecord struct {
+	Conversation Conversation `json:"conversation"`
+	Messages     []Message    `json:"messages"`
+	Ratings      []Rating     `json:"ratings"`
+}
+
+// exportPageSize bounds how many messages are fetched per conversation in
+// a single ListMessagesAfter call while assembling an ExportRecord.
+const exportPageSize = 500
+
+// StreamExport streams every conversation (optionally filtered to those
+// updated at or after since, and/or to a single sessionID) with its
+// messages and ratings attached, without materializing the whole export in
+// memory. The error channel carries at most one error and is closed
+// alongside the record channel.
+//
+// out is unbuffered, so the producer goroutine below selects on ctx.Done()
+// around every send: a caller that stops draining out (e.g. an HTTP export
+// handler whose client disconnected) must cancel ctx, or the goroutine would
+// otherwise block on that send forever, leaking the goroutine and pinning
+// the single pooled connection database.New configures. See
+// ConvertMessageStream for the same pattern applied to message streaming.
+func (db *DB) StreamExport(ctx context.Context, since *time.Time, sessionID string) (<-chan ExportRecord, <-chan error) {
+	out := make(chan ExportRecord)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+		defer close(errCh)
+
+		query := `SELECT id, session_id, title, created_at, updated_at, prompt_count, total_characters, working_directory, transcript_path FROM conversations`
+		var conditions []string
+		var args []interface{}
+		if since != nil {
+			conditions = append(conditions, "updated_at >= ?")
+			args = append(args, *since)
+		}
+		if sessionID != "" {
+			conditions = append(conditions, "session_id = ?")
+			args = append(args, sessionID)
+		}
+		if len(conditions) > 0 {
+			query += " WHERE " + strings.Join(conditions, " AND ")
+		}
+		query += " ORDER BY id ASC"
+
+		rows, err := db.conn.QueryContext(ctx, db.Rebind(query), args...)
+		if err != nil {
+			errCh <- fmt.Errorf("failed to query conversations for export: %w", err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var conv Conversation
+			if err := rows.Scan(
+				&conv.ID, &conv.SessionID, &conv.Title, &conv.CreatedAt, &conv.UpdatedAt,
+				&conv.PromptCount, &conv.TotalCharacters, &conv.WorkingDirectory, &conv.TranscriptPath,
+			); err != nil {
+				errCh <- fmt.Errorf("failed to scan conversation for export: %w", err)
+				return
+			}
+
+			messages, err := db.allMessagesForConversation(conv.ID)
+			if err != nil {
+				errCh <- err
+				return
+			}
+
+			ratings, err := db.GetConversationRatings(conv.ID)
+			if err != nil {
+				errCh <- fmt.Errorf("failed to load ratings for conversation %d: %w", conv.ID, err)
+				return
+			}
+
+			select {
+			case out <- ExportRecord{Conversation: conv, Messages: messages, Ratings: ratings}:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			errCh <- err
+		}
+	}()
+
+	return out, errCh
+}
+
+// allMessagesForConversation walks ListMessagesAfter's keyset cursor to
+// collect every message for a conversation, rather than exposing the
+// cursor to export callers that need the whole history up front.
+func (db *DB) allMessagesForConversation(conversationID int) ([]Message, error) {
+	var all []Message
+	afterID := 0
+
+	for {
+		rows, err := db.ListMessagesAfter(conversationID, afterID, exportPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list messages for conversation %d: %w", conversationID, err)
+		}
+
+		var page []Message
+		for rows.Next() {
+			var msg Message
+			if err := rows.Scan(
+				&msg.ID, &msg.ConversationID, &msg.MessageType, &msg.Content,
+				&msg.CharacterCount, &msg.Timestamp, &msg.ToolCalls, &msg.ExecutionTime,
+			); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan message for conversation %d: %w", conversationID, err)
+			}
+			page = append(page, msg)
+		}
+		rowsErr := rows.Err()
+		rows.Close()
+		if rowsErr != nil {
+			return nil, rowsErr
+		}
+
+		all = append(all, page...)
+		if len(page) < exportPageSize {
+			return all, nil
+		}
+		afterID = page[len(page)-1].ID
+	}
+}
+
+// ImportConversations upserts records into the database inside a single
+// transaction, keyed on session_id so re-importing the same backup updates
+// rather than duplicates each conversation. Messages and ratings are
+// appended fresh under the (possibly reused) conversation row. onProgress,
+// if non-nil, is called after each record is written into the transaction
+// (before the single commit at the end) so callers can surface import
+// progress (e.g. over the SSE bus).
+func (db *DB) ImportConversations(records []ExportRecord, onProgress func(processed, total int)) (int, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin import transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	imported := 0
+	for _, rec := range records {
+		if _, err := tx.Exec(db.Rebind(`
+			INSERT INTO conversations (session_id, title, working_directory, transcript_path)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(session_id) DO UPDATE SET
+				title = excluded.title,
+				working_directory = excluded.working_directory,
+				transcript_path = excluded.transcript_path,
+				updated_at = CURRENT_TIMESTAMP
+		`), rec.Conversation.SessionID, rec.Conversation.Title, rec.Conversation.WorkingDirectory, rec.Conversation.TranscriptPath); err != nil {
+			return imported, fmt.Errorf("failed to upsert conversation %s: %w", rec.Conversation.SessionID, err)
+		}
+
+		var conversationID int
+		if err := tx.QueryRow(db.Rebind(`SELECT id FROM conversations WHERE session_id = ?`), rec.Conversation.SessionID).Scan(&conversationID); err != nil {
+			return imported, fmt.Errorf("failed to look up imported conversation %s: %w", rec.Conversation.SessionID, err)
+		}
+
+		for _, msg := range rec.Messages {
+			if _, err := tx.Exec(db.Rebind(`
+				INSERT INTO messages (conversation_id, message_type, content, character_count, timestamp, tool_calls, execution_time)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
+			`), conversationID, msg.MessageType, msg.Content, msg.CharacterCount, msg.Timestamp, msg.ToolCalls, msg.ExecutionTime); err != nil {
+				return imported, fmt.Errorf("failed to import message for conversation %s: %w", rec.Conversation.SessionID, err)
+			}
+		}
+
+		for _, rating := range rec.Ratings {
+			if _, err := tx.Exec(db.Rebind(`
+				INSERT INTO ratings (conversation_id, message_id, rating, comment)
+				VALUES (?, ?, ?, ?)
+			`), conversationID, rating.MessageID, rating.Rating, rating.Comment); err != nil {
+				return imported, fmt.Errorf("failed to import rating for conversation %s: %w", rec.Conversation.SessionID, err)
+			}
+		}
+
+		imported++
+		if onProgress != nil {
+			onProgress(imported, len(records))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return imported, fmt.Errorf("failed to commit import transaction: %w", err)
+	}
+
+	return imported, nil
+}
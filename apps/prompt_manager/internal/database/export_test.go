@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStreamExport_IncludesMessagesAndRatings(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	conv, err := db.CreateConversation("export-session", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+	if _, err := db.CreateMessage(conv.ID, "prompt", "hello", nil, nil); err != nil {
+		t.Fatalf("Failed to create message: %v", err)
+	}
+	if _, err := db.CreateConversationRating(conv.ID, 5, nil); err != nil {
+		t.Fatalf("Failed to create rating: %v", err)
+	}
+
+	records, errCh := db.StreamExport(context.Background(), nil, "")
+
+	var got []ExportRecord
+	for rec := range records {
+		got = append(got, rec)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("StreamExport failed: %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 exported conversation, got %d", len(got))
+	}
+	if len(got[0].Messages) != 1 {
+		t.Errorf("Expected 1 exported message, got %d", len(got[0].Messages))
+	}
+	if len(got[0].Ratings) != 1 {
+		t.Errorf("Expected 1 exported rating, got %d", len(got[0].Ratings))
+	}
+}
+
+func TestStreamExport_FiltersBySessionID(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	wanted, err := db.CreateConversation("export-session-a", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+	if _, err := db.CreateConversation("export-session-b", nil, nil, nil); err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+
+	records, errCh := db.StreamExport(context.Background(), nil, "export-session-a")
+
+	var got []ExportRecord
+	for rec := range records {
+		got = append(got, rec)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("StreamExport failed: %v", err)
+	}
+
+	if len(got) != 1 || got[0].Conversation.ID != wanted.ID {
+		t.Fatalf("Expected only %q exported, got %+v", "export-session-a", got)
+	}
+}
+
+func TestStreamExport_CancelledContextStopsProducerInsteadOfBlocking(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// Two conversations so the unbuffered out channel still has an unread
+	// record queued up when we stop draining it below.
+	if _, err := db.CreateConversation("export-session-a", nil, nil, nil); err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+	if _, err := db.CreateConversation("export-session-b", nil, nil, nil); err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	records, errCh := db.StreamExport(ctx, nil, "")
+
+	<-records
+	cancel()
+
+	// A caller that stops draining records after cancelling ctx must still
+	// see the channel close, rather than the producer goroutine blocking
+	// forever on the second record's send.
+	done := make(chan struct{})
+	go func() {
+		for range records {
+		}
+		<-errCh
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected StreamExport's producer goroutine to stop after ctx was cancelled, it's still blocked")
+	}
+}
+
+func TestImportConversations_UpsertsBySessionID(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	title := "updated title"
+	records := []ExportRecord{
+		{
+			Conversation: Conversation{SessionID: "import-session", Title: &title},
+			Messages: []Message{
+				{MessageType: "prompt", Content: "imported message", CharacterCount: 16},
+			},
+		},
+	}
+
+	var progressed int
+	imported, err := db.ImportConversations(records, func(processed, total int) {
+		progressed = processed
+		if total != 1 {
+			t.Errorf("Expected progress total of 1, got %d", total)
+		}
+	})
+	if err != nil {
+		t.Fatalf("ImportConversations failed: %v", err)
+	}
+	if imported != 1 {
+		t.Fatalf("Expected 1 imported conversation, got %d", imported)
+	}
+	if progressed != 1 {
+		t.Errorf("Expected onProgress to report 1 processed, got %d", progressed)
+	}
+
+	conv, found, err := db.GetConversationBySessionID(context.Background(), "import-session")
+	if err != nil || !found {
+		t.Fatalf("Expected imported conversation to be findable by session_id, found=%v err=%v", found, err)
+	}
+	if conv.Title == nil || *conv.Title != title {
+		t.Errorf("Expected imported title %q, got %v", title, conv.Title)
+	}
+
+	// Re-importing the same session_id should update, not duplicate, the conversation.
+	if _, err := db.ImportConversations(records, nil); err != nil {
+		t.Fatalf("Second ImportConversations failed: %v", err)
+	}
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats["conversations"] != 1 {
+		t.Errorf("Expected re-import to upsert rather than duplicate, got conversations=%v", stats["conversations"])
+	}
+}
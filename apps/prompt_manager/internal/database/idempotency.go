@@ -0,0 +1,140 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// IdempotencyTTL bounds how long a stored response is replayed before a
+// repeated Idempotency-Key is treated as expired and the request runs fresh.
+const IdempotencyTTL = 24 * time.Hour
+
+// IdempotencyRecord is the stored outcome of a request made with an
+// Idempotency-Key header, so a retry can replay it instead of re-running
+// the handler.
+type IdempotencyRecord struct {
+	Key          string
+	SessionID    string
+	BodyHash     string
+	StatusCode   int
+	ResponseBody []byte
+	CreatedAt    time.Time
+}
+
+// GetIdempotencyRecord returns the stored record for key, or nil if none
+// exists yet, it is still reserved by an in-flight handler (see
+// ReserveIdempotencyKey), or it is older than IdempotencyTTL.
+func (db *DB) GetIdempotencyRecord(key string) (*IdempotencyRecord, error) {
+	var rec IdempotencyRecord
+	err := db.conn.QueryRow(db.Rebind(`
+		SELECT key, session_id, body_hash, status_code, response_body, created_at
+		FROM idempotency_keys
+		WHERE key = ? AND status_code != ?
+	`), key, reservedStatusCode).Scan(&rec.Key, &rec.SessionID, &rec.BodyHash, &rec.StatusCode, &rec.ResponseBody, &rec.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+
+	if time.Since(rec.CreatedAt) > IdempotencyTTL {
+		return nil, nil
+	}
+
+	return &rec, nil
+}
+
+// SaveIdempotencyRecord persists rec so a retried request presenting the
+// same key can replay it. If a concurrent request already won the race to
+// insert the same key, rec is discarded and the existing row stands.
+func (db *DB) SaveIdempotencyRecord(rec IdempotencyRecord) error {
+	if _, err := db.conn.Exec(db.Rebind(`
+		INSERT INTO idempotency_keys (key, session_id, body_hash, status_code, response_body)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(key) DO NOTHING
+	`), rec.Key, rec.SessionID, rec.BodyHash, rec.StatusCode, rec.ResponseBody); err != nil {
+		return fmt.Errorf("failed to save idempotency record: %w", err)
+	}
+	return nil
+}
+
+// reservedStatusCode marks a row ReserveIdempotencyKey inserted for a
+// handler invocation that hasn't finished yet. It's never a real HTTP
+// status, so GetIdempotencyRecord can use it to tell a completed record
+// apart from one a concurrent request is still in the middle of.
+const reservedStatusCode = 0
+
+// ReserveIdempotencyKey inserts a placeholder row for key before the
+// request's handler runs, so two concurrent retries presenting the same key
+// can't both find no existing record and both execute the handler's side
+// effects. won is true if this call's insert placed the row; false means
+// another request already reserved or completed key and the caller must not
+// proceed. A successful reservation is later finalized with
+// CompleteIdempotencyRecord, or undone with ReleaseIdempotencyKey if the
+// handler fails.
+//
+// A row older than IdempotencyTTL is deleted before the insert is attempted,
+// in the same transaction, so a key that expired between requests is
+// reusable again rather than permanently losing every future reservation to
+// ON CONFLICT(key) DO NOTHING against a row GetIdempotencyRecord itself
+// already treats as gone.
+func (db *DB) ReserveIdempotencyKey(key, sessionID, bodyHash string) (won bool, err error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(db.Rebind(`
+		DELETE FROM idempotency_keys WHERE key = ? AND created_at < ?
+	`), key, time.Now().Add(-IdempotencyTTL)); err != nil {
+		return false, fmt.Errorf("failed to expire idempotency key: %w", err)
+	}
+
+	res, err := tx.Exec(db.Rebind(`
+		INSERT INTO idempotency_keys (key, session_id, body_hash, status_code, response_body)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(key) DO NOTHING
+	`), key, sessionID, bodyHash, reservedStatusCode, []byte{})
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check reservation result: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// CompleteIdempotencyRecord fills in the real outcome of a key previously
+// reserved by ReserveIdempotencyKey, once the handler has run.
+func (db *DB) CompleteIdempotencyRecord(rec IdempotencyRecord) error {
+	if _, err := db.conn.Exec(db.Rebind(`
+		UPDATE idempotency_keys
+		SET body_hash = ?, status_code = ?, response_body = ?
+		WHERE key = ?
+	`), rec.BodyHash, rec.StatusCode, rec.ResponseBody, rec.Key); err != nil {
+		return fmt.Errorf("failed to complete idempotency record: %w", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey removes a reservation made by ReserveIdempotencyKey
+// whose handler failed, so a later retry with the same key isn't
+// permanently blocked by a reservation that will never be completed. It's a
+// no-op if key was already completed (or released) by the time this runs.
+func (db *DB) ReleaseIdempotencyKey(key string) error {
+	if _, err := db.conn.Exec(db.Rebind(`
+		DELETE FROM idempotency_keys WHERE key = ? AND status_code = ?
+	`), key, reservedStatusCode); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSaveIdempotencyRecord_RoundTripsAndIgnoresConflict(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	rec := IdempotencyRecord{
+		Key:          "key-1",
+		SessionID:    "session-1",
+		BodyHash:     "abc123",
+		StatusCode:   201,
+		ResponseBody: []byte(`{"success":true}`),
+	}
+	if err := db.SaveIdempotencyRecord(rec); err != nil {
+		t.Fatalf("Failed to save idempotency record: %v", err)
+	}
+
+	got, err := db.GetIdempotencyRecord("key-1")
+	if err != nil {
+		t.Fatalf("Failed to get idempotency record: %v", err)
+	}
+	if got == nil {
+		t.Fatal("Expected a stored record, got nil")
+	}
+	if got.BodyHash != rec.BodyHash || got.StatusCode != rec.StatusCode || string(got.ResponseBody) != string(rec.ResponseBody) {
+		t.Errorf("Expected round-tripped record to match, got %+v", got)
+	}
+
+	// A concurrent writer losing the insert race should not overwrite the
+	// winning record.
+	if err := db.SaveIdempotencyRecord(IdempotencyRecord{
+		Key:          "key-1",
+		BodyHash:     "different-hash",
+		StatusCode:   500,
+		ResponseBody: []byte("ignored"),
+	}); err != nil {
+		t.Fatalf("Saving a conflicting key should be a no-op, got error: %v", err)
+	}
+
+	got, err = db.GetIdempotencyRecord("key-1")
+	if err != nil {
+		t.Fatalf("Failed to get idempotency record: %v", err)
+	}
+	if got.BodyHash != rec.BodyHash {
+		t.Errorf("Expected the original record to survive a conflicting save, got body hash %q", got.BodyHash)
+	}
+}
+
+func TestGetIdempotencyRecord_MissingKeyReturnsNil(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	got, err := db.GetIdempotencyRecord("does-not-exist")
+	if err != nil {
+		t.Fatalf("Expected no error for a missing key, got %v", err)
+	}
+	if got != nil {
+		t.Errorf("Expected nil for a missing key, got %+v", got)
+	}
+}
+
+func TestReserveIdempotencyKey_SecondReservationLoses(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	won, err := db.ReserveIdempotencyKey("key-reserve", "session-1", "hash-a")
+	if err != nil {
+		t.Fatalf("Failed to reserve idempotency key: %v", err)
+	}
+	if !won {
+		t.Fatal("Expected the first reservation to win")
+	}
+
+	// GetIdempotencyRecord must not surface a reservation that hasn't been
+	// completed yet, or a concurrent request would replay a response that
+	// was never actually produced.
+	got, err := db.GetIdempotencyRecord("key-reserve")
+	if err != nil {
+		t.Fatalf("Failed to get idempotency record: %v", err)
+	}
+	if got != nil {
+		t.Errorf("Expected an in-flight reservation to stay invisible to GetIdempotencyRecord, got %+v", got)
+	}
+
+	won, err = db.ReserveIdempotencyKey("key-reserve", "session-1", "hash-b")
+	if err != nil {
+		t.Fatalf("Failed to attempt second reservation: %v", err)
+	}
+	if won {
+		t.Fatal("Expected the second reservation for the same key to lose")
+	}
+}
+
+func TestCompleteIdempotencyRecord_MakesRecordVisible(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if _, err := db.ReserveIdempotencyKey("key-complete", "session-1", "hash-a"); err != nil {
+		t.Fatalf("Failed to reserve idempotency key: %v", err)
+	}
+
+	if err := db.CompleteIdempotencyRecord(IdempotencyRecord{
+		Key:          "key-complete",
+		SessionID:    "session-1",
+		BodyHash:     "hash-a",
+		StatusCode:   201,
+		ResponseBody: []byte(`{"success":true}`),
+	}); err != nil {
+		t.Fatalf("Failed to complete idempotency record: %v", err)
+	}
+
+	got, err := db.GetIdempotencyRecord("key-complete")
+	if err != nil {
+		t.Fatalf("Failed to get idempotency record: %v", err)
+	}
+	if got == nil {
+		t.Fatal("Expected the completed record to be visible, got nil")
+	}
+	if got.StatusCode != 201 || string(got.ResponseBody) != `{"success":true}` {
+		t.Errorf("Expected the completed record's fields to be stored, got %+v", got)
+	}
+}
+
+func TestReleaseIdempotencyKey_AllowsRetryAfterFailure(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if _, err := db.ReserveIdempotencyKey("key-release", "session-1", "hash-a"); err != nil {
+		t.Fatalf("Failed to reserve idempotency key: %v", err)
+	}
+
+	if err := db.ReleaseIdempotencyKey("key-release"); err != nil {
+		t.Fatalf("Failed to release idempotency key: %v", err)
+	}
+
+	won, err := db.ReserveIdempotencyKey("key-release", "session-1", "hash-b")
+	if err != nil {
+		t.Fatalf("Failed to re-reserve a released idempotency key: %v", err)
+	}
+	if !won {
+		t.Fatal("Expected a retry to be able to reserve a key released after a failed handler")
+	}
+}
+
+func TestReserveIdempotencyKey_ReusableAfterTTLExpires(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if _, err := db.ReserveIdempotencyKey("key-expired", "session-1", "hash-a"); err != nil {
+		t.Fatalf("Failed to reserve idempotency key: %v", err)
+	}
+	if err := db.CompleteIdempotencyRecord(IdempotencyRecord{
+		Key:          "key-expired",
+		SessionID:    "session-1",
+		BodyHash:     "hash-a",
+		StatusCode:   201,
+		ResponseBody: []byte(`{"success":true}`),
+	}); err != nil {
+		t.Fatalf("Failed to complete idempotency record: %v", err)
+	}
+
+	// Age the row past IdempotencyTTL directly, since there's no clock to
+	// inject into ReserveIdempotencyKey.
+	if _, err := db.conn.Exec(
+		"UPDATE idempotency_keys SET created_at = ? WHERE key = ?",
+		time.Now().Add(-IdempotencyTTL-time.Minute), "key-expired",
+	); err != nil {
+		t.Fatalf("Failed to age idempotency key: %v", err)
+	}
+
+	// GetIdempotencyRecord already treats an aged-out row as gone; a retry
+	// reserving the same key must not silently lose to ON CONFLICT DO
+	// NOTHING against that same expired row.
+	won, err := db.ReserveIdempotencyKey("key-expired", "session-1", "hash-b")
+	if err != nil {
+		t.Fatalf("Failed to reserve an expired idempotency key: %v", err)
+	}
+	if !won {
+		t.Fatal("Expected a key past its TTL to be reusable rather than stuck forever")
+	}
+}
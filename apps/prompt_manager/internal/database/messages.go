@@ -0,0 +1,25 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ListMessagesAfter returns a cursor over messages in conversationID with
+// id > afterID, ordered by id and capped at limit rows. It uses a keyset
+// cursor rather than OFFSET so a long-running session with thousands of
+// messages can be paged without re-scanning earlier pages. Callers must
+// close the returned rows.
+func (db *DB) ListMessagesAfter(conversationID, afterID, limit int) (*sql.Rows, error) {
+	rows, err := db.conn.Query(db.Rebind(`
+		SELECT id, conversation_id, message_type, content, character_count, timestamp, tool_calls, execution_time
+		FROM messages
+		WHERE conversation_id = ? AND id > ?
+		ORDER BY id ASC
+		LIMIT ?
+	`), conversationID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query messages: %w", err)
+	}
+	return rows, nil
+}
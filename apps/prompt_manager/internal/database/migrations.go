@@ -0,0 +1,558 @@
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// migrationFilenamePattern extracts the numeric version, descriptive name,
+// optional dialect, and direction from a migration filename, e.g.
+// "010_add_tags.up.sql" -> version 10, name "add_tags", dialect "",
+// direction "up"; "001_initial.postgres.up.sql" -> dialect "postgres". A
+// dialect-less file is shared DDL that loadMigrations falls back to when
+// the configured driver has no dialect-specific file for that version.
+var migrationFilenamePattern = regexp.MustCompile(`^(\d+)_(.+?)(?:\.(sqlite|postgres))?\.(up|down)\.sql$`)
+
+// migration is one NNN_name pair of up/down SQL files. downSQL is empty
+// when no matching .down.sql file exists on disk, which applyDown treats
+// as a clear error rather than silently doing nothing.
+type migration struct {
+	version  int
+	name     string
+	upSQL    string
+	downSQL  string
+	checksum string // SHA-256 of the up file, hex-encoded
+}
+
+// MigrationStatus reports one on-disk migration and whether (and when) it
+// has been applied to this database, as returned by DB.Status.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	Checksum  string
+	Dirty     bool
+}
+
+// appliedRecord is the row schema_migrations stores for one applied
+// migration. dirty is true when the migration's transaction was recorded as
+// started but never recorded as finished (e.g. the process crashed
+// mid-migration); it's surfaced read-only via DB.Stats and DB.Status for
+// an operator to notice, not auto-repaired.
+type appliedRecord struct {
+	checksum  string
+	appliedAt time.Time
+	dirty     bool
+}
+
+// SchemaVersion is the highest migration version this binary was built
+// against. DB.CheckCompatibility compares it against the highest version
+// recorded in a database's schema_migrations to catch an old binary
+// connecting to a database a newer binary already migrated.
+const SchemaVersion = 5
+
+// versionKey is the zero-padded string schema_migrations stores a version
+// under, matching the historical 3-digit filename prefix ("002", "010")
+// rather than a bare int, so databases that already have rows recorded by
+// earlier versions of RunMigrations still match up.
+func versionKey(version int) string {
+	return fmt.Sprintf("%03d", version)
+}
+
+// versionFiles collects every up/down file found for one version, keyed by
+// dialect ("" for a dialect-less shared file), so loadMigrations can pick
+// the best match for the configured driver after scanning the whole
+// directory.
+type versionFiles struct {
+	name string
+	up   map[string][]byte
+	down map[string][]byte
+}
+
+// pickDialectFile returns files[dialect] if present, falling back to the
+// dialect-less shared file (files[""]).
+func pickDialectFile(files map[string][]byte, dialect string) ([]byte, bool) {
+	if content, ok := files[dialect]; ok {
+		return content, true
+	}
+	content, ok := files[""]
+	return content, ok
+}
+
+// loadMigrations reads every *.up.sql / *.down.sql file from the root of
+// fsys and returns them sorted numerically by version (so 10 sorts after 2,
+// unlike a lexical sort of the filenames). fsys is typically os.DirFS(dir)
+// for dev-mode reloading from a plain path, or an embed.FS (via fs.Sub, to
+// drop the package-relative prefix) for a statically compiled binary. For
+// each version, a file suffixed with dialect (e.g. ".postgres.up.sql") is
+// preferred over the dialect-less shared file with the same version/name;
+// a version with no file usable for dialect is an error.
+func loadMigrations(fsys fs.FS, dialect string) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	byVersion := make(map[int]*versionFiles)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		parts := migrationFilenamePattern.FindStringSubmatch(entry.Name())
+		if parts == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse migration version from %s: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", entry.Name(), err)
+		}
+
+		vf, ok := byVersion[version]
+		if !ok {
+			vf = &versionFiles{name: parts[2], up: map[string][]byte{}, down: map[string][]byte{}}
+			byVersion[version] = vf
+		}
+
+		switch parts[4] {
+		case "up":
+			vf.up[parts[3]] = content
+		case "down":
+			vf.down[parts[3]] = content
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for version, vf := range byVersion {
+		upSQL, ok := pickDialectFile(vf.up, dialect)
+		if !ok {
+			return nil, fmt.Errorf("migration %03d_%s has no .up.sql usable for driver %q", version, vf.name, dialect)
+		}
+		downSQL, _ := pickDialectFile(vf.down, dialect)
+		sum := sha256.Sum256(upSQL)
+
+		migrations = append(migrations, migration{
+			version:  version,
+			name:     vf.name,
+			upSQL:    string(upSQL),
+			downSQL:  string(downSQL),
+			checksum: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// ensureMigrationsTable creates schema_migrations if it doesn't exist, and
+// adds the name/checksum/dirty columns to installs upgraded from an older
+// schema.
+func (db *DB) ensureMigrationsTable() error {
+	if _, err := db.conn.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version TEXT PRIMARY KEY,
+			name TEXT NOT NULL DEFAULT '',
+			checksum TEXT NOT NULL DEFAULT '',
+			dirty BOOLEAN NOT NULL DEFAULT 0,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	upgrades := []struct{ column, definition string }{
+		{"name", "TEXT NOT NULL DEFAULT ''"},
+		{"checksum", "TEXT NOT NULL DEFAULT ''"},
+		{"dirty", "BOOLEAN NOT NULL DEFAULT 0"},
+	}
+	for _, upgrade := range upgrades {
+		has, err := db.hasColumn("schema_migrations", upgrade.column)
+		if err != nil {
+			return err
+		}
+		if !has {
+			if _, err := db.conn.Exec(fmt.Sprintf("ALTER TABLE schema_migrations ADD COLUMN %s %s", upgrade.column, upgrade.definition)); err != nil {
+				return fmt.Errorf("failed to add %s column to schema_migrations: %w", upgrade.column, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) hasColumn(table, column string) (bool, error) {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect %s columns: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return false, fmt.Errorf("failed to scan %s column info: %w", table, err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// appliedMigrations loads every row currently recorded in schema_migrations,
+// dirty or not; RunMigrationsFS treats any recorded row (even a dirty one)
+// as already applied rather than retrying a non-idempotent DDL statement
+// that may have partially run.
+func (db *DB) appliedMigrations() (map[string]appliedRecord, error) {
+	rows, err := db.conn.Query("SELECT version, checksum, applied_at, dirty FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]appliedRecord)
+	for rows.Next() {
+		var version, checksum string
+		var appliedAt time.Time
+		var dirty bool
+		if err := rows.Scan(&version, &checksum, &appliedAt, &dirty); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[version] = appliedRecord{checksum: checksum, appliedAt: appliedAt, dirty: dirty}
+	}
+	return applied, rows.Err()
+}
+
+// migrationsSummary reports the highest version recorded in
+// schema_migrations (0 if none) and whether any recorded row is still
+// marked dirty, for DB.CheckCompatibility and the schema_version/
+// schema_dirty keys DB.Stats exposes.
+func (db *DB) migrationsSummary() (highest int, dirty bool, err error) {
+	if err := db.ensureMigrationsTable(); err != nil {
+		return 0, false, err
+	}
+
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return 0, false, err
+	}
+
+	for versionStr, rec := range applied {
+		if rec.dirty {
+			dirty = true
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			continue
+		}
+		if version > highest {
+			highest = version
+		}
+	}
+
+	return highest, dirty, nil
+}
+
+// applyUp marks m dirty, runs its up SQL, and clears the dirty flag, with
+// the DDL and the flag clear in one transaction so a failure there leaves
+// dirty set rather than silently looking finished. Marking dirty uses an
+// upsert so retrying a migration left dirty by a previous crash doesn't
+// fail on the row it already inserted.
+func (db *DB) applyUp(m migration) error {
+	markDirty := `INSERT INTO schema_migrations (version, name, checksum, dirty) VALUES (?, ?, ?, 1) ` +
+		db.driver.Upsert("version", []string{"name", "checksum", "dirty"})
+	if _, err := db.conn.Exec(db.Rebind(markDirty), versionKey(m.version), m.name, m.checksum); err != nil {
+		return fmt.Errorf("failed to mark migration %s_%s dirty: %w", versionKey(m.version), m.name, err)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.upSQL); err != nil {
+		return fmt.Errorf("failed to apply migration %s_%s: %w", versionKey(m.version), m.name, err)
+	}
+
+	if _, err := tx.Exec(db.Rebind("UPDATE schema_migrations SET dirty = 0 WHERE version = ?"), versionKey(m.version)); err != nil {
+		return fmt.Errorf("failed to clear dirty flag for migration %s_%s: %w", versionKey(m.version), m.name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %s_%s: %w", versionKey(m.version), m.name, err)
+	}
+
+	fmt.Printf("Applied migration: %s_%s\n", versionKey(m.version), m.name)
+	return nil
+}
+
+// applyDown runs m's down SQL and removes it from schema_migrations, both
+// in one transaction. It errors if m has no .down.sql file rather than
+// silently leaving the migration applied.
+func (db *DB) applyDown(m migration) error {
+	if m.downSQL == "" {
+		return fmt.Errorf("no down migration available for %s_%s", versionKey(m.version), m.name)
+	}
+
+	if _, err := db.conn.Exec(db.Rebind("UPDATE schema_migrations SET dirty = 1 WHERE version = ?"), versionKey(m.version)); err != nil {
+		return fmt.Errorf("failed to mark migration %s_%s dirty: %w", versionKey(m.version), m.name, err)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin rollback transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.downSQL); err != nil {
+		return fmt.Errorf("failed to roll back migration %s_%s: %w", versionKey(m.version), m.name, err)
+	}
+
+	if _, err := tx.Exec(db.Rebind("DELETE FROM schema_migrations WHERE version = ?"), versionKey(m.version)); err != nil {
+		return fmt.Errorf("failed to unrecord migration %s_%s: %w", versionKey(m.version), m.name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rollback of migration %s_%s: %w", versionKey(m.version), m.name, err)
+	}
+
+	fmt.Printf("Rolled back migration: %s_%s\n", versionKey(m.version), m.name)
+	return nil
+}
+
+// RunMigrations applies every migration under migrationsDir that hasn't
+// already run. It's a thin wrapper around RunMigrationsFS(os.DirFS(dir)) so
+// dev mode keeps reading migrations straight off disk (edits are picked up
+// on the next call, no rebuild needed); a statically compiled binary should
+// call RunMigrationsFS directly with an embedded filesystem instead.
+func (db *DB) RunMigrations(migrationsDir string) error {
+	return db.RunMigrationsFS(os.DirFS(migrationsDir))
+}
+
+// RunMigrationsFS applies every migration at the root of fsys that hasn't
+// already run, in numeric version order (so 10 runs after 2, unlike a
+// lexical sort of the filenames), each in its own transaction. An
+// already-applied migration is checksummed against its .up.sql file; a
+// mismatch means the file was edited after being applied, which is a
+// startup-time error rather than a silent divergence between environments.
+// fsys is remembered on db so MigrateTo, Rollback, Redo, and Status don't
+// need it passed again. Pass os.DirFS(dir) for a plain directory, or an
+// embed.FS rooted at the migrations directory to ship the schema inside a
+// single static binary (see database/migrations.FS).
+func (db *DB) RunMigrationsFS(fsys fs.FS) error {
+	db.migrationsFS = fsys
+
+	if err := db.ensureMigrationsTable(); err != nil {
+		return err
+	}
+
+	migrations, err := loadMigrations(fsys, db.driver.Name())
+	if err != nil {
+		return err
+	}
+
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		rec, ok := applied[versionKey(m.version)]
+		if !ok {
+			if err := db.applyUp(m); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if rec.checksum != m.checksum {
+			return fmt.Errorf(
+				"migration %s_%s has changed since it was applied on %s (checksum mismatch); edit a new migration instead of changing history",
+				versionKey(m.version), m.name, rec.appliedAt.Format(time.RFC3339),
+			)
+		}
+	}
+
+	return nil
+}
+
+// CheckCompatibility compares the highest migration version recorded in
+// schema_migrations against SchemaVersion, the version this binary was
+// built against. A database ahead of the binary (highest > SchemaVersion)
+// means an older binary connected to a database a newer one already
+// migrated; refusing to start here is cheaper than the missing-column
+// errors and silent data corruption that would otherwise follow. A database
+// behind the binary is migrated automatically only when Config.AutoMigrate
+// is set; otherwise it also refuses to start, so an operator runs
+// migrations explicitly rather than have them kick in unexpectedly.
+// db.migrationsFS must already be set (New populates it from
+// Config.MigrationsFS or Config.MigrationsDir) when AutoMigrate is true.
+func (db *DB) CheckCompatibility() error {
+	highest, _, err := db.migrationsSummary()
+	if err != nil {
+		return err
+	}
+
+	if highest > SchemaVersion {
+		return fmt.Errorf("database schema is at version %d, ahead of this binary's version %d; upgrade the binary before connecting", highest, SchemaVersion)
+	}
+
+	if highest < SchemaVersion {
+		if !db.autoMigrate {
+			return fmt.Errorf("database schema is at version %d, behind this binary's version %d; run migrations or set Config.AutoMigrate", highest, SchemaVersion)
+		}
+		if db.migrationsFS == nil {
+			return fmt.Errorf("cannot auto-migrate: no migrations directory or filesystem configured")
+		}
+		return db.RunMigrationsFS(db.migrationsFS)
+	}
+
+	return nil
+}
+
+// MigrateTo applies or rolls back migrations until exactly the migrations
+// with version <= target have been applied. A target of 0 rolls everything
+// back. Migrations are read from the filesystem New set up from Config, or
+// from the one the last RunMigrations/RunMigrationsFS call was given.
+func (db *DB) MigrateTo(version string) error {
+	target, err := strconv.Atoi(version)
+	if err != nil {
+		return fmt.Errorf("invalid target version %q: %w", version, err)
+	}
+
+	migrations, err := loadMigrations(db.migrationsFS, db.driver.Name())
+	if err != nil {
+		return err
+	}
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version > target {
+			continue
+		}
+		if _, ok := applied[versionKey(m.version)]; !ok {
+			if err := db.applyUp(m); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Roll back anything above target in reverse (highest version first),
+	// so a migration's down script never runs against a schema a later
+	// migration already altered further.
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.version <= target {
+			continue
+		}
+		if _, ok := applied[versionKey(m.version)]; ok {
+			if err := db.applyDown(m); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Rollback rolls back the steps most recently applied migrations, highest
+// version first.
+func (db *DB) Rollback(steps int) error {
+	migrations, err := loadMigrations(db.migrationsFS, db.driver.Name())
+	if err != nil {
+		return err
+	}
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	rolledBack := 0
+	for i := len(migrations) - 1; i >= 0 && rolledBack < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[versionKey(m.version)]; !ok {
+			continue
+		}
+		if err := db.applyDown(m); err != nil {
+			return err
+		}
+		rolledBack++
+	}
+
+	return nil
+}
+
+// Redo rolls back the most recently applied migration and reapplies it,
+// useful while iterating on a migration's SQL without typing out a
+// separate Rollback(1) followed by MigrateTo.
+func (db *DB) Redo() error {
+	migrations, err := loadMigrations(db.migrationsFS, db.driver.Name())
+	if err != nil {
+		return err
+	}
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if _, ok := applied[versionKey(m.version)]; !ok {
+			continue
+		}
+		if err := db.applyDown(m); err != nil {
+			return err
+		}
+		return db.applyUp(m)
+	}
+
+	return fmt.Errorf("no applied migrations to redo")
+}
+
+// Status reports every migration found under the migrations directory
+// alongside whether (and when) it has been applied to this database.
+func (db *DB) Status() ([]MigrationStatus, error) {
+	migrations, err := loadMigrations(db.migrationsFS, db.driver.Name())
+	if err != nil {
+		return nil, err
+	}
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.version, Name: m.name, Checksum: m.checksum}
+		if rec, ok := applied[versionKey(m.version)]; ok {
+			status.Applied = true
+			status.Dirty = rec.dirty
+			appliedAt := rec.appliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
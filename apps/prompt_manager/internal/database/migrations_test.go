@@ -0,0 +1,291 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/claude-code-template/prompt-manager/database/migrations"
+)
+
+// writeMigrationFixture writes a numbered up (and, if down != "", down)
+// migration pair under dir.
+func writeMigrationFixture(t *testing.T, dir string, version int, name, up, down string) {
+	t.Helper()
+
+	base := filepath.Join(dir, versionKey(version)+"_"+name)
+	if err := os.WriteFile(base+".up.sql", []byte(up), 0o644); err != nil {
+		t.Fatalf("Failed to write up fixture: %v", err)
+	}
+	if down != "" {
+		if err := os.WriteFile(base+".down.sql", []byte(down), 0o644); err != nil {
+			t.Fatalf("Failed to write down fixture: %v", err)
+		}
+	}
+}
+
+func newMigrationsTestDB(t *testing.T) (*DB, string) {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	migrationsDir := t.TempDir()
+
+	db, err := New(&Config{DatabasePath: dbPath, MigrationsDir: migrationsDir})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db, migrationsDir
+}
+
+func TestRunMigrations_SortsNumericallyNotLexically(t *testing.T) {
+	db, dir := newMigrationsTestDB(t)
+
+	writeMigrationFixture(t, dir, 2, "second", "CREATE TABLE t2 (id INTEGER);", "DROP TABLE t2;")
+	writeMigrationFixture(t, dir, 10, "tenth", "CREATE TABLE t10 (id INTEGER);", "DROP TABLE t10;")
+
+	if err := db.RunMigrations(dir); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	statuses, err := db.Status()
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(statuses))
+	}
+	if statuses[0].Version != 2 || statuses[1].Version != 10 {
+		t.Errorf("Expected versions in numeric order [2, 10], got [%d, %d]", statuses[0].Version, statuses[1].Version)
+	}
+	if !statuses[0].Applied || !statuses[1].Applied {
+		t.Error("Expected both migrations to be applied")
+	}
+}
+
+func TestRunMigrations_ChecksumMismatchOnHistoricalEditErrors(t *testing.T) {
+	db, dir := newMigrationsTestDB(t)
+
+	writeMigrationFixture(t, dir, 1, "create_widgets", "CREATE TABLE widgets (id INTEGER);", "DROP TABLE widgets;")
+	if err := db.RunMigrations(dir); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	// Edit the already-applied migration's up file in place.
+	writeMigrationFixture(t, dir, 1, "create_widgets", "CREATE TABLE widgets (id INTEGER, name TEXT);", "DROP TABLE widgets;")
+
+	if err := db.RunMigrations(dir); err == nil {
+		t.Fatal("Expected RunMigrations to error on a checksum mismatch for an already-applied migration")
+	}
+}
+
+func TestRollback_RevertsMostRecentlyAppliedMigrationsFirst(t *testing.T) {
+	db, dir := newMigrationsTestDB(t)
+
+	writeMigrationFixture(t, dir, 1, "create_widgets", "CREATE TABLE widgets (id INTEGER);", "DROP TABLE widgets;")
+	writeMigrationFixture(t, dir, 2, "create_gadgets", "CREATE TABLE gadgets (id INTEGER);", "DROP TABLE gadgets;")
+	if err := db.RunMigrations(dir); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	if err := db.Rollback(1); err != nil {
+		t.Fatalf("Failed to roll back: %v", err)
+	}
+
+	statuses, err := db.Status()
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	if !statuses[0].Applied {
+		t.Error("Expected version 1 to remain applied")
+	}
+	if statuses[1].Applied {
+		t.Error("Expected version 2 (the most recently applied) to have been rolled back")
+	}
+
+	if _, err := db.conn.Exec("SELECT 1 FROM gadgets"); err == nil {
+		t.Error("Expected the gadgets table to have been dropped by the rollback")
+	}
+}
+
+func TestRollback_MissingDownMigrationReturnsClearError(t *testing.T) {
+	db, dir := newMigrationsTestDB(t)
+
+	writeMigrationFixture(t, dir, 1, "create_widgets", "CREATE TABLE widgets (id INTEGER);", "")
+	if err := db.RunMigrations(dir); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	if err := db.Rollback(1); err == nil {
+		t.Fatal("Expected Rollback to error when no .down.sql file exists")
+	}
+}
+
+func TestMigrateTo_AppliesUpToTargetAndRollsBackAboveIt(t *testing.T) {
+	db, dir := newMigrationsTestDB(t)
+
+	writeMigrationFixture(t, dir, 1, "create_widgets", "CREATE TABLE widgets (id INTEGER);", "DROP TABLE widgets;")
+	writeMigrationFixture(t, dir, 2, "create_gadgets", "CREATE TABLE gadgets (id INTEGER);", "DROP TABLE gadgets;")
+	writeMigrationFixture(t, dir, 3, "create_gizmos", "CREATE TABLE gizmos (id INTEGER);", "DROP TABLE gizmos;")
+	if err := db.RunMigrations(dir); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	if err := db.MigrateTo("1"); err != nil {
+		t.Fatalf("Failed to migrate to version 1: %v", err)
+	}
+
+	statuses, err := db.Status()
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	if !statuses[0].Applied {
+		t.Error("Expected version 1 to remain applied")
+	}
+	if statuses[1].Applied || statuses[2].Applied {
+		t.Error("Expected versions 2 and 3 to have been rolled back")
+	}
+}
+
+func TestRedo_RollsBackAndReappliesMostRecentMigration(t *testing.T) {
+	db, dir := newMigrationsTestDB(t)
+
+	writeMigrationFixture(t, dir, 1, "create_widgets", "CREATE TABLE widgets (id INTEGER);", "DROP TABLE widgets;")
+	if err := db.RunMigrations(dir); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	if _, err := db.conn.Exec("INSERT INTO widgets (id) VALUES (1)"); err != nil {
+		t.Fatalf("Failed to insert into widgets: %v", err)
+	}
+
+	if err := db.Redo(); err != nil {
+		t.Fatalf("Failed to redo: %v", err)
+	}
+
+	var count int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM widgets").Scan(&count); err != nil {
+		t.Fatalf("Failed to count widgets after redo: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected Redo to have recreated an empty widgets table, found %d rows", count)
+	}
+}
+
+func TestRunMigrationsFS_AppliesEmbeddedMigrationsDirectory(t *testing.T) {
+	db, _ := newMigrationsTestDB(t)
+
+	if err := db.RunMigrationsFS(migrations.FS); err != nil {
+		t.Fatalf("Failed to run embedded migrations: %v", err)
+	}
+
+	statuses, err := db.Status()
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	if len(statuses) == 0 {
+		t.Fatal("Expected the embedded migrations directory to contain at least one migration")
+	}
+	for _, status := range statuses {
+		if !status.Applied {
+			t.Errorf("Expected embedded migration %d (%s) to be applied", status.Version, status.Name)
+		}
+	}
+}
+
+func TestCheckCompatibility_DatabaseAheadOfBinaryErrors(t *testing.T) {
+	db, dir := newMigrationsTestDB(t)
+
+	writeMigrationFixture(t, dir, SchemaVersion+1, "from_the_future", "CREATE TABLE future (id INTEGER);", "DROP TABLE future;")
+	if err := db.RunMigrations(dir); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	if err := db.CheckCompatibility(); err == nil {
+		t.Fatal("Expected CheckCompatibility to error when the database is ahead of SchemaVersion")
+	}
+}
+
+func TestCheckCompatibility_DatabaseBehindWithoutAutoMigrateErrors(t *testing.T) {
+	db, _ := newMigrationsTestDB(t)
+
+	if err := db.CheckCompatibility(); err == nil {
+		t.Fatal("Expected CheckCompatibility to error when the database is behind SchemaVersion and AutoMigrate is unset")
+	}
+}
+
+func TestCheckCompatibility_DatabaseAtCurrentVersionSucceeds(t *testing.T) {
+	db, dir := newMigrationsTestDB(t)
+
+	writeMigrationFixture(t, dir, SchemaVersion, "catch_up", "CREATE TABLE catch_up (id INTEGER);", "DROP TABLE catch_up;")
+	if err := db.RunMigrations(dir); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	if err := db.CheckCompatibility(); err != nil {
+		t.Errorf("Expected CheckCompatibility to succeed once the database matches SchemaVersion, got: %v", err)
+	}
+}
+
+func TestMigrationsSummary_ReportsDirtyAfterInterruptedMigration(t *testing.T) {
+	db, dir := newMigrationsTestDB(t)
+
+	writeMigrationFixture(t, dir, 1, "create_widgets", "CREATE TABLE widgets (id INTEGER);", "DROP TABLE widgets;")
+	if err := db.RunMigrations(dir); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	// Simulate a process that crashed after marking a migration dirty but
+	// before running its DDL.
+	if _, err := db.conn.Exec(
+		"INSERT INTO schema_migrations (version, name, checksum, dirty) VALUES (?, ?, ?, 1)",
+		versionKey(2), "create_gadgets", "deadbeef",
+	); err != nil {
+		t.Fatalf("Failed to seed a dirty migration row: %v", err)
+	}
+
+	highest, dirty, err := db.migrationsSummary()
+	if err != nil {
+		t.Fatalf("Failed to summarize migrations: %v", err)
+	}
+	if !dirty {
+		t.Error("Expected migrationsSummary to report dirty=true")
+	}
+	if highest != 2 {
+		t.Errorf("Expected the dirty row's version to count toward highest, got %d", highest)
+	}
+}
+
+func TestCheckCompatibility_AutoMigrateAppliesPendingMigrations(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	migrationsDir := t.TempDir()
+	writeMigrationFixture(t, migrationsDir, 1, "create_widgets", "CREATE TABLE widgets (id INTEGER);", "DROP TABLE widgets;")
+
+	db, err := New(&Config{DatabasePath: dbPath, MigrationsDir: migrationsDir, AutoMigrate: true})
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// Write the rest of the fixtures up to SchemaVersion only after New, to
+	// confirm CheckCompatibility (not New) is what triggers auto-migration.
+	for v := 2; v <= SchemaVersion; v++ {
+		writeMigrationFixture(t, migrationsDir, v, fmt.Sprintf("step_%d", v), fmt.Sprintf("CREATE TABLE step_%d (id INTEGER);", v), fmt.Sprintf("DROP TABLE step_%d;", v))
+	}
+
+	if err := db.CheckCompatibility(); err != nil {
+		t.Fatalf("Expected CheckCompatibility to auto-migrate rather than error: %v", err)
+	}
+
+	statuses, err := db.Status()
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	for _, status := range statuses {
+		if !status.Applied {
+			t.Errorf("Expected migration %d (%s) to have been auto-migrated, found unapplied", status.Version, status.Name)
+		}
+	}
+}
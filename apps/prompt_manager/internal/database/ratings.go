@@ -0,0 +1,49 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// CreateMessageRating records a rating against a specific message (as
+// opposed to CreateConversationRating's conversation-level ratings),
+// looking up the message's conversation so the row carries both IDs.
+func (db *DB) CreateMessageRating(messageID, rating int, comment *string) (*Rating, error) {
+	var conversationID int
+	if err := db.conn.QueryRow(db.Rebind(`SELECT conversation_id FROM messages WHERE id = ?`), messageID).Scan(&conversationID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("message not found")
+		}
+		return nil, fmt.Errorf("failed to look up message: %w", err)
+	}
+
+	result, err := db.conn.Exec(db.Rebind(`
+		INSERT INTO ratings (conversation_id, message_id, rating, comment)
+		VALUES (?, ?, ?, ?)
+	`), conversationID, messageID, rating, comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create message rating: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inserted rating id: %w", err)
+	}
+
+	return db.GetRating(int(id))
+}
+
+// GetConversationAverageRating returns the mean of a conversation's
+// conversation-level and message-level ratings, or nil if it has none.
+func (db *DB) GetConversationAverageRating(conversationID int) (*float64, error) {
+	var avg sql.NullFloat64
+	if err := db.conn.QueryRow(db.Rebind(`
+		SELECT AVG(rating) FROM ratings WHERE conversation_id = ?
+	`), conversationID).Scan(&avg); err != nil {
+		return nil, fmt.Errorf("failed to get average rating: %w", err)
+	}
+	if !avg.Valid {
+		return nil, nil
+	}
+	return &avg.Float64, nil
+}
@@ -0,0 +1,20 @@
+package database
+
+// Reranker reorders the top-N BM25 candidates from a Search call, e.g. by
+// cosine similarity against an embedding backend (a local ONNX model or a
+// remote HTTP re-ranking service). Search invokes it, when non-nil, against
+// a candidate pool (see rerankCandidatePoolSize) fetched before limit/offset
+// are applied, so it can actually reorder results across the whole pool
+// rather than just within whatever page BM25 happened to put them on.
+type Reranker interface {
+	RerankMessages(query string, hits []MessageHit) []MessageHit
+}
+
+// NoopReranker leaves BM25 ordering untouched. It is the default when no
+// embedding backend is configured.
+type NoopReranker struct{}
+
+// RerankMessages implements Reranker.
+func (NoopReranker) RerankMessages(_ string, hits []MessageHit) []MessageHit {
+	return hits
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// MessageHit is a single FTS5 match against messages.content.
+type MessageHit struct {
+	Message
+	Snippet string
+	Score   float64
+}
+
+// ConversationHit is a single FTS5 match against conversations.title.
+type ConversationHit struct {
+	Conversation
+	Snippet string
+	Score   float64
+}
+
+// SearchFilters narrows a Search call to a facet of the corpus. Scope is
+// "messages", "conversations", or "" to search both.
+type SearchFilters struct {
+	Scope     string
+	From      *time.Time
+	To        *time.Time
+	MinRating *int
+	SessionID string
+}
+
+// SearchResults holds the ranked hits for a Search call.
+type SearchResults struct {
+	Messages      []MessageHit      `json:"messages,omitempty"`
+	Conversations []ConversationHit `json:"conversations,omitempty"`
+}
+
+// rerankCandidatePoolSize bounds how many BM25-ranked message hits a
+// reranker is given to reorder. A reranker needs more than just the
+// requested page to be able to promote a lower-BM25-ranked hit above it, but
+// fetching every FTS match for a broad query would be unbounded, so the
+// candidate pool is capped at a generous but finite size instead. A query
+// whose offset+limit falls outside this pool loses reranking for that page
+// and falls back to BM25 order.
+const rerankCandidatePoolSize = 200
+
+// Search runs an FTS5 MATCH query against messages and/or conversations
+// (depending on filters.Scope), returning BM25-ranked hits with highlighted
+// snippets. If reranker is non-nil, it reorders the message candidates
+// (e.g. by embedding similarity) before limit/offset are applied, by
+// fetching up to rerankCandidatePoolSize candidates, reranking that whole
+// pool, and then slicing out the requested page. A page that reaches past
+// that pool is served in BM25 order without reranking.
+//
+// FTS5, bm25(), and snippet() are SQLite-specific; there's no Postgres
+// equivalent to Rebind the placeholders into, so Search is gated the same
+// way admin.go gates SQLite-only operations rather than pretending a
+// placeholder rewrite would make it portable.
+func (db *DB) Search(query string, filters SearchFilters, limit, offset int, reranker Reranker) (*SearchResults, error) {
+	if db.driver.Name() != "sqlite" {
+		return nil, errUnsupportedOnDriver("Search", db.driver.Name())
+	}
+
+	results := &SearchResults{}
+
+	if filters.Scope == "" || filters.Scope == "messages" {
+		if reranker != nil && offset+limit <= rerankCandidatePoolSize {
+			hits, err := db.searchMessages(query, filters, rerankCandidatePoolSize, 0)
+			if err != nil {
+				return nil, fmt.Errorf("failed to search messages: %w", err)
+			}
+			hits = reranker.RerankMessages(query, hits)
+			results.Messages = paginateMessageHits(hits, limit, offset)
+		} else {
+			hits, err := db.searchMessages(query, filters, limit, offset)
+			if err != nil {
+				return nil, fmt.Errorf("failed to search messages: %w", err)
+			}
+			results.Messages = hits
+		}
+	}
+
+	if filters.Scope == "" || filters.Scope == "conversations" {
+		hits, err := db.searchConversations(query, limit, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search conversations: %w", err)
+		}
+		results.Conversations = hits
+	}
+
+	return results, nil
+}
+
+// paginateMessageHits slices out the requested page from a reranked
+// candidate pool, returning nil if offset falls beyond it.
+func paginateMessageHits(hits []MessageHit, limit, offset int) []MessageHit {
+	if offset >= len(hits) {
+		return nil
+	}
+	end := offset + limit
+	if end > len(hits) {
+		end = len(hits)
+	}
+	return hits[offset:end]
+}
+
+func (db *DB) searchMessages(query string, filters SearchFilters, limit, offset int) ([]MessageHit, error) {
+	sqlQuery := `
+		SELECT m.id, m.conversation_id, m.message_type, m.content, m.character_count, m.timestamp, m.tool_calls, m.execution_time,
+		       snippet(messages_fts, 0, '<mark>', '</mark>', '...', 10) AS snippet,
+		       bm25(messages_fts) AS score
+		FROM messages_fts
+		JOIN messages m ON m.id = messages_fts.rowid
+		WHERE messages_fts MATCH ?
+	`
+	args := []interface{}{query}
+
+	if filters.SessionID != "" {
+		sqlQuery += " AND m.conversation_id IN (SELECT id FROM conversations WHERE session_id = ?)"
+		args = append(args, filters.SessionID)
+	}
+	if filters.From != nil {
+		sqlQuery += " AND m.timestamp >= ?"
+		args = append(args, *filters.From)
+	}
+	if filters.To != nil {
+		sqlQuery += " AND m.timestamp <= ?"
+		args = append(args, *filters.To)
+	}
+	if filters.MinRating != nil {
+		sqlQuery += " AND m.id IN (SELECT message_id FROM ratings WHERE message_id IS NOT NULL AND rating >= ?)"
+		args = append(args, *filters.MinRating)
+	}
+
+	sqlQuery += " ORDER BY score LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := db.conn.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []MessageHit
+	for rows.Next() {
+		var hit MessageHit
+		if err := rows.Scan(
+			&hit.ID, &hit.ConversationID, &hit.MessageType, &hit.Content, &hit.CharacterCount, &hit.Timestamp, &hit.ToolCalls, &hit.ExecutionTime,
+			&hit.Snippet, &hit.Score,
+		); err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+func (db *DB) searchConversations(query string, limit, offset int) ([]ConversationHit, error) {
+	rows, err := db.conn.Query(`
+		SELECT c.id, c.session_id, c.title, c.created_at, c.updated_at, c.prompt_count, c.total_characters, c.working_directory, c.transcript_path,
+		       snippet(conversations_fts, 0, '<mark>', '</mark>', '...', 10) AS snippet,
+		       bm25(conversations_fts) AS score
+		FROM conversations_fts
+		JOIN conversations c ON c.id = conversations_fts.rowid
+		WHERE conversations_fts MATCH ?
+		ORDER BY score
+		LIMIT ? OFFSET ?
+	`, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []ConversationHit
+	for rows.Next() {
+		var hit ConversationHit
+		if err := rows.Scan(
+			&hit.ID, &hit.SessionID, &hit.Title, &hit.CreatedAt, &hit.UpdatedAt, &hit.PromptCount, &hit.TotalCharacters, &hit.WorkingDirectory, &hit.TranscriptPath,
+			&hit.Snippet, &hit.Score,
+		); err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
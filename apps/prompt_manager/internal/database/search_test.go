@@ -0,0 +1,123 @@
+package database
+
+import "testing"
+
+func TestSearch_MessagesScopeMatchesContent(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	conv, err := db.CreateConversation("search-session", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+
+	if _, err := db.CreateMessage(conv.ID, "prompt", "how do I configure the FTS5 search index", nil, nil); err != nil {
+		t.Fatalf("Failed to create message: %v", err)
+	}
+	if _, err := db.CreateMessage(conv.ID, "prompt", "unrelated content about something else", nil, nil); err != nil {
+		t.Fatalf("Failed to create message: %v", err)
+	}
+
+	results, err := db.Search("FTS5", SearchFilters{Scope: "messages"}, 10, 0, NoopReranker{})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	if len(results.Messages) != 1 {
+		t.Fatalf("Expected 1 matching message, got %d", len(results.Messages))
+	}
+	if results.Conversations != nil {
+		t.Errorf("Expected no conversation hits with scope=messages, got %d", len(results.Conversations))
+	}
+	if results.Messages[0].Snippet == "" {
+		t.Error("Expected a non-empty snippet")
+	}
+}
+
+func TestSearch_SessionIDFilterExcludesOtherConversations(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	convA, err := db.CreateConversation("session-a", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+	convB, err := db.CreateConversation("session-b", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+
+	if _, err := db.CreateMessage(convA.ID, "prompt", "database migration rollback plan", nil, nil); err != nil {
+		t.Fatalf("Failed to create message: %v", err)
+	}
+	if _, err := db.CreateMessage(convB.ID, "prompt", "database migration rollback plan", nil, nil); err != nil {
+		t.Fatalf("Failed to create message: %v", err)
+	}
+
+	results, err := db.Search("migration", SearchFilters{Scope: "messages", SessionID: "session-a"}, 10, 0, NoopReranker{})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	if len(results.Messages) != 1 {
+		t.Fatalf("Expected 1 matching message scoped to session-a, got %d", len(results.Messages))
+	}
+	if results.Messages[0].ConversationID != convA.ID {
+		t.Errorf("Expected hit from conversation %d, got %d", convA.ID, results.Messages[0].ConversationID)
+	}
+}
+
+// reverseReranker reverses BM25 order, so a test can tell whether reranking
+// ran over the whole candidate pool (promoting the worst BM25 match to the
+// top) or only within whatever page had already been sliced out by limit/
+// offset (which would leave a one-message page unchanged).
+type reverseReranker struct{}
+
+func (reverseReranker) RerankMessages(_ string, hits []MessageHit) []MessageHit {
+	reversed := make([]MessageHit, len(hits))
+	for i, hit := range hits {
+		reversed[len(hits)-1-i] = hit
+	}
+	return reversed
+}
+
+func TestSearch_RerankerSeesFullCandidatePoolBeforePagination(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	conv, err := db.CreateConversation("rerank-session", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+
+	// Three matches with increasing term frequency, listed worst, second,
+	// best by BM25 (repeating "widget" scores higher relevance).
+	var ids []int
+	for _, content := range []string{
+		"widget",
+		"widget widget",
+		"widget widget widget",
+	} {
+		msg, err := db.CreateMessage(conv.ID, "prompt", content, nil, nil)
+		if err != nil {
+			t.Fatalf("Failed to create message: %v", err)
+		}
+		ids = append(ids, msg.ID)
+	}
+
+	results, err := db.Search("widget", SearchFilters{Scope: "messages"}, 1, 0, reverseReranker{})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results.Messages) != 1 {
+		t.Fatalf("Expected 1 message on the requested page, got %d", len(results.Messages))
+	}
+
+	// BM25's best match is "widget widget widget" (ids[2]); reversing it
+	// over the whole 3-hit candidate pool promotes the worst match,
+	// "widget" (ids[0]), to the single returned page. If reranking had only
+	// seen an already-paginated 1-hit page, this would still be ids[2].
+	if results.Messages[0].ID != ids[0] {
+		t.Errorf("Expected the reranker to reorder across the full candidate pool and return message %d, got %d", ids[0], results.Messages[0].ID)
+	}
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// AddConversationTag attaches tag name to a conversation, creating the tag
+// if it doesn't already exist. Attaching a tag that's already on the
+// conversation is a no-op.
+func (db *DB) AddConversationTag(conversationID int, name string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(db.Rebind(`INSERT INTO tags (name) VALUES (?) ON CONFLICT(name) DO NOTHING`), name); err != nil {
+		return fmt.Errorf("failed to upsert tag: %w", err)
+	}
+
+	var tagID int
+	if err := tx.QueryRow(db.Rebind(`SELECT id FROM tags WHERE name = ?`), name).Scan(&tagID); err != nil {
+		return fmt.Errorf("failed to look up tag: %w", err)
+	}
+
+	if _, err := tx.Exec(db.Rebind(`
+		INSERT INTO conversation_tags (conversation_id, tag_id) VALUES (?, ?)
+		ON CONFLICT(conversation_id, tag_id) DO NOTHING
+	`), conversationID, tagID); err != nil {
+		return fmt.Errorf("failed to attach tag: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RemoveConversationTag detaches tag name from a conversation. Removing a
+// tag that isn't attached is a no-op; the tags row itself is left in
+// place in case other conversations still reference it.
+func (db *DB) RemoveConversationTag(conversationID int, name string) error {
+	if _, err := db.conn.Exec(db.Rebind(`
+		DELETE FROM conversation_tags
+		WHERE conversation_id = ? AND tag_id = (SELECT id FROM tags WHERE name = ?)
+	`), conversationID, name); err != nil {
+		return fmt.Errorf("failed to remove tag: %w", err)
+	}
+	return nil
+}
+
+// GetConversationTags returns the tag names attached to a conversation, in
+// alphabetical order.
+func (db *DB) GetConversationTags(conversationID int) ([]string, error) {
+	rows, err := db.conn.Query(db.Rebind(`
+		SELECT t.name
+		FROM tags t
+		JOIN conversation_tags ct ON ct.tag_id = t.id
+		WHERE ct.conversation_id = ?
+		ORDER BY t.name ASC
+	`), conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation tags: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTagNames(rows)
+}
+
+// ListTags returns every tag name in use, in alphabetical order.
+func (db *DB) ListTags() ([]string, error) {
+	rows, err := db.conn.Query(`SELECT name FROM tags ORDER BY name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTagNames(rows)
+}
+
+func scanTagNames(rows *sql.Rows) ([]string, error) {
+	tags := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
@@ -0,0 +1,111 @@
+package database
+
+import "testing"
+
+func TestAddConversationTag_IsIdempotentAndListable(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	conv, err := db.CreateConversation("tag-session", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+
+	if err := db.AddConversationTag(conv.ID, "bug"); err != nil {
+		t.Fatalf("Failed to add tag: %v", err)
+	}
+	if err := db.AddConversationTag(conv.ID, "bug"); err != nil {
+		t.Fatalf("Re-adding the same tag should be a no-op, got error: %v", err)
+	}
+
+	tags, err := db.GetConversationTags(conv.ID)
+	if err != nil {
+		t.Fatalf("Failed to get conversation tags: %v", err)
+	}
+	if len(tags) != 1 || tags[0] != "bug" {
+		t.Errorf("Expected tags [bug], got %v", tags)
+	}
+
+	all, err := db.ListTags()
+	if err != nil {
+		t.Fatalf("Failed to list tags: %v", err)
+	}
+	if len(all) != 1 || all[0] != "bug" {
+		t.Errorf("Expected all tags [bug], got %v", all)
+	}
+}
+
+func TestRemoveConversationTag_DetachesWithoutError(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	conv, err := db.CreateConversation("untag-session", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+
+	if err := db.AddConversationTag(conv.ID, "feature"); err != nil {
+		t.Fatalf("Failed to add tag: %v", err)
+	}
+	if err := db.RemoveConversationTag(conv.ID, "feature"); err != nil {
+		t.Fatalf("Failed to remove tag: %v", err)
+	}
+	if err := db.RemoveConversationTag(conv.ID, "never-attached"); err != nil {
+		t.Fatalf("Removing an unattached tag should be a no-op, got error: %v", err)
+	}
+
+	tags, err := db.GetConversationTags(conv.ID)
+	if err != nil {
+		t.Fatalf("Failed to get conversation tags: %v", err)
+	}
+	if len(tags) != 0 {
+		t.Errorf("Expected no tags after removal, got %v", tags)
+	}
+}
+
+func TestListConversationsFiltered_FiltersByTagAndMinAvgRating(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	tagged, err := db.CreateConversation("tagged-session", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+	if err := db.AddConversationTag(tagged.ID, "important"); err != nil {
+		t.Fatalf("Failed to add tag: %v", err)
+	}
+	if _, err := db.CreateConversationRating(tagged.ID, 5, nil); err != nil {
+		t.Fatalf("Failed to create rating: %v", err)
+	}
+
+	untagged, err := db.CreateConversation("untagged-session", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+	if _, err := db.CreateConversationRating(untagged.ID, 1, nil); err != nil {
+		t.Fatalf("Failed to create rating: %v", err)
+	}
+
+	byTag, err := db.ListConversationsFiltered(20, 0, "important", nil)
+	if err != nil {
+		t.Fatalf("ListConversationsFiltered failed: %v", err)
+	}
+	if len(byTag) != 1 || byTag[0].ID != tagged.ID {
+		t.Fatalf("Expected only the tagged conversation, got %+v", byTag)
+	}
+	if byTag[0].AverageRating == nil || *byTag[0].AverageRating != 5 {
+		t.Errorf("Expected average rating 5, got %v", byTag[0].AverageRating)
+	}
+	if len(byTag[0].Tags) != 1 || byTag[0].Tags[0] != "important" {
+		t.Errorf("Expected tags [important], got %v", byTag[0].Tags)
+	}
+
+	minRating := 3.0
+	byRating, err := db.ListConversationsFiltered(20, 0, "", &minRating)
+	if err != nil {
+		t.Fatalf("ListConversationsFiltered failed: %v", err)
+	}
+	if len(byRating) != 1 || byRating[0].ID != tagged.ID {
+		t.Fatalf("Expected only the highly-rated conversation, got %+v", byRating)
+	}
+}
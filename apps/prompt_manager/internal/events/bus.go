@@ -0,0 +1,149 @@
+// Package events provides topic-based pub/sub fan-out used to push live
+// updates (new messages, ratings, conversation changes) to SSE subscribers
+// without polling the database.
+package events
+
+import (
+	"fmt"
+	"sync"
+)
+
+// GlobalTopic carries every conversation, message, and rating event,
+// regardless of which conversation it belongs to.
+const GlobalTopic = "global"
+
+// ConversationTopic scopes events to a single conversation, so a client
+// watching one conversation isn't woken up by every other one.
+func ConversationTopic(conversationID int) string {
+	return fmt.Sprintf("conversation:%d", conversationID)
+}
+
+// ringBufferSize bounds how many recent events per topic are retained for
+// Last-Event-ID replay when a client reconnects.
+const ringBufferSize = 256
+
+// subscriberBufferSize bounds how far a slow subscriber can lag behind a
+// topic before it is dropped rather than blocking publishers.
+const subscriberBufferSize = 32
+
+// Event is a single published message, tagged with a monotonically
+// increasing ID (scoped to its topic) so subscribers can resume with
+// Last-Event-ID.
+type Event struct {
+	ID    int64
+	Topic string
+	Type  string
+	Data  interface{}
+}
+
+// Bus is a topic-based, buffered-channel fan-out hub. The zero value is not
+// usable; construct with NewBus.
+type Bus struct {
+	mu     sync.Mutex
+	topics map[string]*topic
+}
+
+type topic struct {
+	nextID int64
+	ring   []Event
+	subs   map[*Subscriber]struct{}
+}
+
+// Subscriber receives events published to the topics it subscribed to.
+// Events arrive on C; callers must drain it to avoid missing pings. Close
+// unsubscribes and releases the channel.
+type Subscriber struct {
+	C <-chan Event
+
+	bus   *Bus
+	ch    chan Event
+	topic string
+}
+
+// NewBus creates an empty Bus ready for Subscribe/Publish.
+func NewBus() *Bus {
+	return &Bus{topics: make(map[string]*topic)}
+}
+
+func (b *Bus) getOrCreateTopic(name string) *topic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic{subs: make(map[*Subscriber]struct{})}
+		b.topics[name] = t
+	}
+	return t
+}
+
+// Subscribe registers for events on the given topic. If lastEventID is
+// non-zero, any buffered events with a greater ID are replayed on C before
+// live events arrive.
+func (b *Bus) Subscribe(topicName string, lastEventID int64) *Subscriber {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.getOrCreateTopic(topicName)
+
+	var replay []Event
+	if lastEventID > 0 {
+		for _, ev := range t.ring {
+			if ev.ID > lastEventID {
+				replay = append(replay, ev)
+			}
+		}
+	}
+
+	// ch must hold the whole replay backlog plus subscriberBufferSize of
+	// slack for live events, or a reconnect with a backlog bigger than the
+	// channel would block this send below while b.mu is held, freezing
+	// Publish/Subscribe for every topic.
+	ch := make(chan Event, len(replay)+subscriberBufferSize)
+	sub := &Subscriber{C: ch, bus: b, ch: ch, topic: topicName}
+
+	for _, ev := range replay {
+		ch <- ev
+	}
+
+	t.subs[sub] = struct{}{}
+	return sub
+}
+
+// Close unsubscribes s from its topic. Safe to call more than once.
+func (s *Subscriber) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	t, ok := s.bus.topics[s.topic]
+	if !ok {
+		return
+	}
+	if _, subscribed := t.subs[s]; subscribed {
+		delete(t.subs, s)
+		close(s.ch)
+	}
+}
+
+// Publish fans eventType/data out to every subscriber of topicName,
+// recording it in the topic's replay ring. Slow subscribers (a full
+// buffer) are skipped for this event rather than blocking the publisher.
+func (b *Bus) Publish(topicName, eventType string, data interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.getOrCreateTopic(topicName)
+	t.nextID++
+	ev := Event{ID: t.nextID, Topic: topicName, Type: eventType, Data: data}
+
+	t.ring = append(t.ring, ev)
+	if len(t.ring) > ringBufferSize {
+		t.ring = t.ring[len(t.ring)-ringBufferSize:]
+	}
+
+	for sub := range t.subs {
+		select {
+		case sub.ch <- ev:
+		default:
+			// Subscriber is lagging; drop this event for them rather than
+			// stalling every other subscriber on the topic.
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package events
+
+import "testing"
+
+func TestBus_PublishDeliversToSubscriber(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe("conversations", 0)
+	defer sub.Close()
+
+	bus.Publish("conversations", "conversation.created", map[string]int{"id": 1})
+
+	select {
+	case ev := <-sub.C:
+		if ev.Type != "conversation.created" {
+			t.Errorf("Expected type conversation.created, got %s", ev.Type)
+		}
+	default:
+		t.Fatal("Expected an event to be delivered")
+	}
+}
+
+func TestBus_PublishDoesNotCrossTopics(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe("conversations", 0)
+	defer sub.Close()
+
+	bus.Publish("ratings", "rating.updated", nil)
+
+	select {
+	case ev := <-sub.C:
+		t.Fatalf("Expected no event from a different topic, got %v", ev)
+	default:
+	}
+}
+
+func TestBus_SubscribeReplaysEventsAfterLastEventID(t *testing.T) {
+	bus := NewBus()
+
+	bus.Publish("conversations", "conversation.created", 1)
+	bus.Publish("conversations", "conversation.created", 2)
+	bus.Publish("conversations", "conversation.created", 3)
+
+	sub := bus.Subscribe("conversations", 1)
+	defer sub.Close()
+
+	var got []interface{}
+	for i := 0; i < 2; i++ {
+		got = append(got, (<-sub.C).Data)
+	}
+
+	if len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Errorf("Expected replay of events 2 and 3, got %v", got)
+	}
+}
+
+func TestBus_SubscribeReplaysBacklogLargerThanSubscriberBuffer(t *testing.T) {
+	bus := NewBus()
+
+	const published = subscriberBufferSize + 64
+	for i := 0; i < published; i++ {
+		bus.Publish("conversations", "conversation.created", i)
+	}
+
+	// Subscribe should return immediately (not block forever queueing the
+	// replay into an undersized channel) even though the backlog since
+	// lastEventID is bigger than subscriberBufferSize.
+	sub := bus.Subscribe("conversations", 0)
+	defer sub.Close()
+
+	for i := 0; i < published; i++ {
+		ev := <-sub.C
+		if ev.Data != i {
+			t.Fatalf("Expected replayed event %d, got %v", i, ev.Data)
+		}
+	}
+}
+
+func TestSubscriber_CloseStopsDelivery(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe("conversations", 0)
+	sub.Close()
+
+	bus.Publish("conversations", "conversation.created", nil)
+
+	if _, ok := <-sub.C; ok {
+		t.Error("Expected channel to be closed after Close")
+	}
+}
@@ -0,0 +1,55 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// heartbeatInterval is how often a comment ping is written to keep
+// intermediate proxies/load balancers from closing an idle SSE connection.
+const heartbeatInterval = 15 * time.Second
+
+// WriteSSE streams sub's events to w as Server-Sent Events until the
+// request context is cancelled (the client disconnects) or a write fails.
+// It blocks, so callers should invoke it directly from an http.HandlerFunc.
+func WriteSSE(w http.ResponseWriter, r *http.Request, sub *Subscriber) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return fmt.Errorf("streaming unsupported")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return err
+			}
+			flusher.Flush()
+		case ev, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+			payload, err := json.Marshal(ev.Data)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, payload); err != nil {
+				return err
+			}
+			flusher.Flush()
+		}
+	}
+}